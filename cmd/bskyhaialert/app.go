@@ -0,0 +1,606 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/xerrors"
+)
+
+// The kinds of the periodic posts and mails, each made at most once in its period.
+const (
+	PERIODIC_WEEKLY      = "weekly"
+	PERIODIC_LEADERBOARD = "leaderboard"
+	PERIODIC_MONTHLY     = "monthly"
+	PERIODIC_YEARLY      = "yearly"
+	PERIODIC_WEEKLY_MAIL = "weekly_mail"
+)
+
+// App is the bot logged in and ready to collect the stats and deliver the reports.
+type App struct {
+	*Templates
+
+	cfg        *Config
+	client     *xrpc.Client
+	poster     *Poster
+	messenger  *Messenger
+	store      *Store
+	mailer     *Mailer
+	ntfy       *Ntfy
+	mq         *Mqtt
+	sinks      []Sink
+	formatters map[string]Formatter
+
+	// dailyMu serializes the daily runs, as the one run by a signal may overlap the scheduled one.
+	dailyMu sync.Mutex
+}
+
+// newApp logs in and sets up the store and the sinks of the config.
+func newApp(ctx context.Context, cfg *Config) (*App, error) {
+	tmpls, err := parseTemplates(cfg)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse templates: %w", err)
+	}
+
+	a := &App{Templates: tmpls, cfg: cfg}
+
+	if _, err := time.Parse(POST_TIME_LAYOUT, cfg.PostTime); err != nil {
+		return nil, xerrors.Errorf("invalid post_time: %w", err)
+	}
+
+	for _, s := range cfg.Schedules {
+		if err := validateSchedule(s); err != nil {
+			return nil, xerrors.Errorf("invalid schedule %s: %w", s.Name, err)
+		}
+	}
+
+	a.formatters, err = parseFormatters(cfg.Formats)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse formats: %w", err)
+	}
+
+	if cfg.NtfyUrl != "" {
+		a.ntfy = newNtfy(cfg.NtfyUrl, cfg.NtfyToken)
+	}
+
+	a.client, err = newClient(ctx, cfg, cfg.Handle, cfg.Password, cfg.AuthFactorToken)
+	if err != nil {
+		if a.ntfy != nil {
+			a.ntfy.alert(ctx, "ログインに失敗しました", err)
+		}
+
+		return nil, xerrors.Errorf("failed to create client: %w", err)
+	}
+
+	reporter := a.client
+	if cfg.ReporterHandle != "" {
+		reporter, err = newClient(ctx, cfg, cfg.ReporterHandle, cfg.ReporterPassword, "")
+		if err != nil {
+			if a.ntfy != nil {
+				a.ntfy.alert(ctx, "ログインに失敗しました", err)
+			}
+
+			return nil, xerrors.Errorf("failed to create reporter client: %w", err)
+		}
+	}
+
+	// The dry-run mode is enabled before the messenger copies the reporter client.
+	if dryRun {
+		enableDryRun(a.client, reporter)
+	}
+
+	a.poster, err = newPoster(reporter, cfg)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create poster: %w", err)
+	}
+
+	switch cfg.Delivery {
+	case DELIVERY_POST, DELIVERY_NONE:
+	case DELIVERY_DM:
+		if cfg.DmRecipient == "" {
+			return nil, xerrors.New("dm_recipient is required for dm delivery")
+		}
+	default:
+		return nil, xerrors.Errorf("unknown delivery: %s", cfg.Delivery)
+	}
+
+	if cfg.MentionSpikeDm && cfg.DmRecipient == "" {
+		return nil, xerrors.New("dm_recipient is required for mention_spike_dm")
+	}
+
+	if cfg.DmRecipient != "" {
+		a.messenger = newMessenger(reporter, cfg.DmRecipient)
+	}
+
+	if cfg.SmtpHost != "" {
+		a.mailer, err = newMailer(cfg)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to create mailer: %w", err)
+		}
+	}
+
+	if cfg.MqttBroker != "" {
+		var discoveryPrefix string
+		if cfg.HomeAssistant {
+			discoveryPrefix = cfg.HomeAssistantPrefix
+		}
+
+		a.mq = newMqtt(cfg.MqttBroker, cfg.MqttClientId, cfg.MqttUsername, cfg.MqttPassword, cfg.MqttTopicPrefix, cfg.Handle, discoveryPrefix)
+	}
+
+	a.store, err = openStore()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to open store: %w", err)
+	}
+
+	switch a.cfg.Delivery {
+	case DELIVERY_POST:
+		a.sinks = append(a.sinks, &PostSink{
+			client:             a.client,
+			poster:             a.poster,
+			store:              a.store,
+			cfg:                a.cfg,
+			headlineTmpl:       a.headline,
+			chartAltTmpl:       a.chartAlt,
+			topPostsTmpl:       a.topPosts,
+			thankFollowersTmpl: a.thankFollowers,
+		})
+	case DELIVERY_DM:
+		a.sinks = append(a.sinks, &DmSink{messenger: a.messenger})
+	}
+
+	if a.cfg.DiscordWebhook != "" {
+		a.sinks = append(a.sinks, &DiscordSink{webhook: a.cfg.DiscordWebhook})
+	}
+
+	if a.cfg.SlackWebhook != "" {
+		a.sinks = append(a.sinks, &SlackSink{webhook: a.cfg.SlackWebhook})
+	}
+
+	if a.cfg.TelegramBotToken != "" {
+		sink := &TelegramSink{token: a.cfg.TelegramBotToken, chatId: a.cfg.TelegramChatId}
+		if a.cfg.TelegramChart {
+			sink.store = a.store
+		}
+
+		a.sinks = append(a.sinks, sink)
+	}
+
+	if a.mailer != nil {
+		a.sinks = append(a.sinks, &MailSink{mailer: a.mailer, store: a.store})
+	}
+
+	if a.ntfy != nil {
+		a.sinks = append(a.sinks, &NtfySink{ntfy: a.ntfy})
+	}
+
+	if a.cfg.MatrixHomeserver != "" {
+		a.sinks = append(a.sinks, &MatrixSink{homeserver: a.cfg.MatrixHomeserver, token: a.cfg.MatrixAccessToken, roomId: a.cfg.MatrixRoomId})
+	}
+
+	if a.cfg.MastodonUrl != "" {
+		a.sinks = append(a.sinks, &MastodonSink{
+			instance:   a.cfg.MastodonUrl,
+			token:      a.cfg.MastodonAccessToken,
+			visibility: a.cfg.MastodonVisibility,
+			tmpl:       a.mastodon,
+		})
+	}
+
+	if a.cfg.MisskeyUrl != "" {
+		a.sinks = append(a.sinks, &MisskeySink{instance: a.cfg.MisskeyUrl, token: a.cfg.MisskeyToken, visibility: a.cfg.MisskeyVisibility})
+	}
+
+	if len(a.cfg.NostrRelays) > 0 {
+		if _, err := parseNostrPrivateKey(a.cfg.NostrPrivateKey); err != nil {
+			return nil, xerrors.Errorf("invalid nostr_private_key: %w", err)
+		}
+
+		a.sinks = append(a.sinks, &NostrSink{relays: a.cfg.NostrRelays, privateKey: a.cfg.NostrPrivateKey})
+	}
+
+	if a.cfg.XConsumerKey != "" {
+		a.sinks = append(a.sinks, &XSink{
+			creds: &XCredentials{
+				ConsumerKey:       a.cfg.XConsumerKey,
+				ConsumerSecret:    a.cfg.XConsumerSecret,
+				AccessToken:       a.cfg.XAccessToken,
+				AccessTokenSecret: a.cfg.XAccessTokenSecret,
+			},
+			tmpl: a.x,
+		})
+	}
+
+	if a.cfg.Atom {
+		a.sinks = append(a.sinks, &AtomSink{store: a.store, path: a.cfg.AtomPath, url: a.cfg.AtomUrl, handle: a.cfg.Handle})
+	}
+
+	return a, nil
+}
+
+func (a *App) Close() error {
+	return a.store.Close()
+}
+
+// initStats records the current stats as the baseline of the day unless one already exists.
+func (a *App) initStats(ctx context.Context, now time.Time) error {
+	data, err := bskystats.FetchData(ctx, a.client)
+	if err != nil {
+		return xerrors.Errorf("failed to fetch data: %w", err)
+	}
+
+	health.fetched(now)
+
+	if err := a.store.InitStats(now, data); err != nil {
+		return xerrors.Errorf("failed to init stats: %w", err)
+	}
+
+	if a.mq != nil {
+		if err := a.mq.publishData(data); err != nil {
+			slog.Error("failed to publish to mqtt", "error", err)
+		}
+	}
+
+	return nil
+}
+
+// runDaily collects the stats of the day before now and delivers the reports due on the day.
+// Unless forced, it does nothing if the job has already run on the day, so that the report is never delivered twice.
+// Even when forced, as by SIGUSR1, the periodic posts and mails already made in their periods are not made again.
+// The run is traced with the fetch and the delivery to each sink as its children.
+func (a *App) runDaily(ctx context.Context, now time.Time, force bool) {
+	a.dailyMu.Lock()
+	defer a.dailyMu.Unlock()
+
+	ctx, span := tracer.Start(ctx, "daily", trace.WithAttributes(attribute.String("date", now.Format(DATE_FORMAT))))
+	defer span.End()
+
+	yesterday := now.AddDate(0, 0, -1)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	logger := slog.With("date", today.Format(DATE_FORMAT))
+
+	start := time.Now()
+	defer func() { logger.Info("daily run finished", "duration", time.Since(start)) }()
+
+	if !force {
+		done, err := a.store.IsDailyRunDone(today)
+		if err != nil {
+			logger.Error("failed to check daily run", "error", err)
+			return
+		}
+
+		if done {
+			logger.Info("the daily job has already run today, skipping")
+			return
+		}
+	}
+
+	previous, err := a.store.GetLatestStatsBefore(now)
+	if err != nil {
+		logger.Error("failed to load previous stats", "error", err)
+		return
+	}
+
+	data := previous.Data
+
+	var newData Data
+	fetchCtx, fetchSpan := tracer.Start(ctx, "fetch")
+	err = a.withSession(fetchCtx, func() error {
+		newData, err = bskystats.FetchData(fetchCtx, a.client)
+		return err
+	})
+	endSpan(fetchSpan, err)
+	if err != nil {
+		logger.Error("failed to update data", "error", err)
+		return
+	}
+
+	health.fetched(now)
+
+	if err := a.store.SaveStats(now, newData); err != nil {
+		logger.Error("failed to save stats", "error", err)
+		return
+	}
+
+	if a.mq != nil {
+		if err := a.mq.publishData(newData); err != nil {
+			logger.Error("failed to publish to mqtt", "error", err)
+		} else {
+			logger.Info("mqtt success")
+		}
+	}
+
+	param := newParam(yesterday, data, newData, a.cfg.RatioPrecision)
+	coverDays(param, previous.Date, today)
+
+	// The providers collect the data of the features enabled in the config, setting it to the param.
+	env := &MetricEnv{
+		Client:  a.client,
+		Store:   a.store,
+		Config:  a.cfg,
+		Now:     now,
+		Today:   today,
+		Data:    data,
+		NewData: newData,
+		Param:   param,
+		Daily:   true,
+	}
+	param.Metrics = collectMetrics(ctx, metricProviders, env)
+
+	if a.cfg.TemplatePath != "" {
+		reloaded, err := loadPostTemplate(a.cfg.TemplatePath, a.funcMap)
+		if err != nil {
+			logger.Error("failed to reload template, keeping the previous one", "error", err)
+		} else {
+			a.post.Store(reloaded)
+		}
+	}
+
+	postTmpl := a.postTemplate(now)
+
+	unchanged := a.cfg.SkipUnchanged && newData == data
+	notable := isNotable(data, newData, a.cfg.MinChange, a.cfg.MinChangeRate)
+
+	var posted bool
+	if a.cfg.DuplicateCheck && a.cfg.Delivery == DELIVERY_POST && !unchanged && notable {
+		posted, err = isDailyPosted(ctx, a.poster.client, a.duplicate, today, param)
+		if err != nil {
+			logger.Error("failed to look for the daily post", "error", err)
+		}
+	}
+
+	// The run is only recorded if the report was delivered to all the sinks or skipped, so that the failed ones are tried again.
+	delivered := true
+
+	switch {
+	case unchanged:
+		logger.Info("nothing changed, skipping delivery")
+	case !notable:
+		logger.Info("no notable change, skipping delivery")
+	case posted:
+		logger.Info("the daily post is already on the feed, skipping delivery")
+	default:
+		report := &Report{
+			Now:      now,
+			Today:    today,
+			Data:     newData,
+			Param:    param,
+			Template: postTmpl,
+			TopPosts: env.TopPosts,
+			Force:    force,
+		}
+
+		deliverCtx, deliverSpan := tracer.Start(ctx, "deliver")
+		delivered = deliverReport(deliverCtx, a.store, a.sinks, a.formatters, report, a.cfg.SinkRetries, a.ntfy)
+		deliverSpan.End()
+	}
+
+	if delivered {
+		health.posted(now)
+
+		if err := a.store.SaveDailyRun(now); err != nil {
+			logger.Error("failed to save daily run", "error", err)
+		}
+	}
+
+	if len(a.cfg.Webhooks) > 0 {
+		if err := sendWebhooks(ctx, a.cfg.Webhooks, a.cfg.WebhookSecret, newData, param); err != nil {
+			logger.Error("failed to send to webhooks", "error", err)
+		} else {
+			logger.Info("webhooks success")
+		}
+	}
+
+	if err := postMilestone(ctx, a.poster, a.store, a.milestone, a.cfg.Milestones, now, data, newData); err != nil {
+		logger.Error("failed to post milestone", "error", err)
+	}
+
+	if a.cfg.Weekly && now.Weekday() == time.Monday {
+		posted, err := a.postPeriodic(PERIODIC_WEEKLY, today.Format(DATE_FORMAT), func() error {
+			return postWeeklySummary(ctx, a.poster, a.store, a.weekly, now)
+		})
+		switch {
+		case err != nil:
+			logger.Error("failed to post weekly summary", "error", err)
+		case posted:
+			logger.Info("weekly summary post success")
+		default:
+			logger.Info("the weekly summary has already been posted, skipping")
+		}
+	}
+
+	if a.mailer != nil && a.cfg.SmtpWeekly && now.Weekday() == time.Monday {
+		mailed, err := a.postPeriodic(PERIODIC_WEEKLY_MAIL, today.Format(DATE_FORMAT), func() error {
+			return mailWeeklySummary(a.mailer, a.store, a.weekly, now)
+		})
+		switch {
+		case err != nil:
+			logger.Error("failed to mail weekly summary", "error", err)
+		case mailed:
+			logger.Info("weekly summary mail success")
+		default:
+			logger.Info("the weekly summary has already been mailed, skipping")
+		}
+	}
+
+	if a.cfg.Leaderboard && now.Weekday() == time.Monday {
+		posted, err := a.postPeriodic(PERIODIC_LEADERBOARD, today.Format(DATE_FORMAT), func() error {
+			return postLeaderboard(ctx, a.client, a.poster, a.leaderboard, now, a.cfg.LeaderboardOptOut)
+		})
+		switch {
+		case err != nil:
+			logger.Error("failed to post leaderboard", "error", err)
+		case posted:
+			logger.Info("leaderboard post success")
+		default:
+			logger.Info("the leaderboard has already been posted, skipping")
+		}
+	}
+
+	if a.cfg.InactiveFollows && now.Weekday() == time.Monday {
+		path, err := writeInactiveFollowsReport(ctx, a.client, now, a.cfg.InactiveDays)
+		if err != nil {
+			logger.Error("failed to write inactive follows report", "error", err)
+		} else {
+			logger.Info("inactive follows report written", "path", path)
+		}
+	}
+
+	if a.cfg.Monthly && now.Day() == 1 {
+		posted, err := a.postPeriodic(PERIODIC_MONTHLY, today.Format(MONTH_FORMAT), func() error {
+			return postMonthlyRecap(ctx, a.poster, a.store, a.monthly, now)
+		})
+		switch {
+		case err != nil:
+			logger.Error("failed to post monthly recap", "error", err)
+		case posted:
+			logger.Info("monthly recap post success")
+		default:
+			logger.Info("the monthly recap has already been posted, skipping")
+		}
+	}
+
+	if a.cfg.Yearly && now.YearDay() == 1 {
+		posted, err := a.postPeriodic(PERIODIC_YEARLY, today.Format(YEAR_FORMAT), func() error {
+			return postYearInReview(ctx, a.poster, a.store, a.yearly, now)
+		})
+		switch {
+		case err != nil:
+			logger.Error("failed to post year in review", "error", err)
+		case posted:
+			logger.Info("year in review post success")
+		default:
+			logger.Info("the year in review has already been posted, skipping")
+		}
+	}
+}
+
+// postPeriodic makes the periodic post of the kind with post unless it has already been made for the period,
+// reporting whether it made the post. Unlike the daily report, it is never made twice, even by a forced run.
+func (a *App) postPeriodic(kind, period string, post func() error) (bool, error) {
+	done, err := a.store.IsPeriodicPosted(kind, period)
+	if err != nil {
+		return false, xerrors.Errorf("failed to check periodic post: %w", err)
+	}
+
+	if done {
+		return false, nil
+	}
+
+	if err := post(); err != nil {
+		return false, err
+	}
+
+	if err := a.store.SavePeriodicPost(kind, period); err != nil {
+		return true, xerrors.Errorf("failed to save periodic post: %w", err)
+	}
+
+	return true, nil
+}
+
+// previewDaily renders the daily post from the current stats and the latest ones recorded before now,
+// without recording or delivering anything.
+func (a *App) previewDaily(ctx context.Context, now time.Time) (string, error) {
+	previous, err := a.store.GetLatestStatsBefore(now)
+	if err != nil {
+		return "", xerrors.Errorf("failed to load previous stats: %w", err)
+	}
+
+	var newData Data
+	err = a.withSession(ctx, func() error {
+		newData, err = bskystats.FetchData(ctx, a.client)
+		return err
+	})
+	if err != nil {
+		return "", xerrors.Errorf("failed to fetch data: %w", err)
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	param := newParam(now.AddDate(0, 0, -1), previous.Data, newData, a.cfg.RatioPrecision)
+	coverDays(param, previous.Date, today)
+
+	text, err := bskystats.RenderTemplate(a.postTemplate(now), param)
+	if err != nil {
+		return "", xerrors.Errorf("failed to render template: %w", err)
+	}
+
+	return text, nil
+}
+
+// newParam returns the param of the counters on the day, given the stats recorded before and after it.
+func newParam(day time.Time, data, newData Data, ratioPrecision int) *Param {
+	return &Param{
+		Yesterday:             day.Format(DATE_FORMAT),
+		Since:                 day.Format(DATE_FORMAT),
+		Days:                  1,
+		PostsCount:            data.Posts,
+		PostsCountDiff:        newData.Posts - data.Posts,
+		FollowsCount:          data.Follows,
+		FollowsCountDiff:      newData.Follows - data.Follows,
+		FollowersCount:        data.Followers,
+		FollowersCountDiff:    newData.Followers - data.Followers,
+		ListsCount:            data.Lists,
+		ListsCountDiff:        newData.Lists - data.Lists,
+		FeedgensCount:         data.Feedgens,
+		FeedgensCountDiff:     newData.Feedgens - data.Feedgens,
+		StarterPacksCount:     data.StarterPacks,
+		StarterPacksCountDiff: newData.StarterPacks - data.StarterPacks,
+		Labeler:               newData.Labeler,
+		Ratio:                 ratio(data),
+		RatioDiff:             ratio(newData) - ratio(data),
+		RatioPrecision:        ratioPrecision,
+	}
+}
+
+// coverDays sets the days the diffs of param cover, from the day the previous stats were recorded on until
+// the day before today. They cover more than a day if the previous runs were missed, as when catching up.
+func coverDays(param *Param, since string, today time.Time) {
+	from, err := time.ParseInLocation(DATE_FORMAT, since, today.Location())
+	if err != nil || !from.Before(today) {
+		return
+	}
+
+	param.Since = since
+	param.Days = int(math.Round(today.Sub(from).Hours() / 24))
+}
+
+// checkMentionSpike alerts if the mentions spiked in the last hour, reporting whether it did.
+// The alert is posted, or sent to the DM recipient if mention_spike_dm is set.
+func (a *App) checkMentionSpike(ctx context.Context, now time.Time) (bool, error) {
+	var param *MentionSpikeParam
+	err := a.withSession(ctx, func() (err error) {
+		param, err = detectMentionSpike(ctx, a.client, now, a.cfg.MentionSpike, a.cfg.MentionSpikeMin)
+		return err
+	})
+	if err != nil || param == nil {
+		return false, err
+	}
+
+	if !a.cfg.MentionSpikeDm {
+		if err := a.poster.postTemplate(ctx, a.mentionSpike, param); err != nil {
+			return false, xerrors.Errorf("failed to post alert: %w", err)
+		}
+
+		return true, nil
+	}
+
+	text, err := bskystats.RenderTemplate(a.mentionSpike, param)
+	if err != nil {
+		return false, xerrors.Errorf("failed to render alert: %w", err)
+	}
+
+	if err := a.messenger.send(ctx, text); err != nil {
+		return false, xerrors.Errorf("failed to send alert: %w", err)
+	}
+
+	return true, nil
+}
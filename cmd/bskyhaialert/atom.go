@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	ATOM_PATH    = REPORT_DIR + "/daily.atom"
+	ATOM_ENTRIES = 30
+	ATOM_NS      = "http://www.w3.org/2005/Atom"
+)
+
+type atomFeed struct {
+	XMLName xml.Name     `xml:"feed"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Id      string       `xml:"id"`
+	Title   string       `xml:"title"`
+	Updated string       `xml:"updated"`
+	Author  *atomAuthor  `xml:"author"`
+	Links   []*atomLink  `xml:"link,omitempty"`
+	Entries []*atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Id      string       `xml:"id"`
+	Title   string       `xml:"title"`
+	Updated string       `xml:"updated"`
+	Content *atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// writeAtomFeed writes the Atom feed of the latest ATOM_ENTRIES daily reports of the handle to the path.
+// If url is not empty, it is linked as the location of the feed.
+func writeAtomFeed(store *Store, path, url, handle string, now time.Time) error {
+	reports, err := store.GetLatestDailyReports(ATOM_ENTRIES)
+	if err != nil {
+		return xerrors.Errorf("failed to get daily reports: %w", err)
+	}
+
+	feed := &atomFeed{
+		Xmlns:   ATOM_NS,
+		Id:      "urn:bskyhaialert:" + handle,
+		Title:   handle + "の統計",
+		Updated: now.Format(time.RFC3339),
+		Author:  &atomAuthor{Name: handle},
+	}
+
+	if url != "" {
+		feed.Links = append(feed.Links, &atomLink{Href: url, Rel: "self"})
+	}
+
+	for _, report := range reports {
+		updated := now
+		if date, err := time.ParseInLocation(DATE_FORMAT, report.Date, now.Location()); err == nil {
+			updated = date.AddDate(0, 0, 1)
+		}
+
+		feed.Entries = append(feed.Entries, &atomEntry{
+			Id:      "urn:bskyhaialert:" + handle + ":" + report.Date,
+			Title:   report.Date + "の統計",
+			Updated: updated.Format(time.RFC3339),
+			Content: &atomContent{Type: "text", Body: report.Text},
+		})
+	}
+
+	b, err := xml.MarshalIndent(feed, "", "\t")
+	if err != nil {
+		return xerrors.Errorf("failed to marshal feed: %w", err)
+	}
+
+	// A relative path is under dataDir like the other reports.
+	if !filepath.IsAbs(path) {
+		path = statePath(path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return xerrors.Errorf("failed to create feed directory: %w", err)
+	}
+
+	if err := writeFile(path, append([]byte(xml.Header), b...), 0o600); err != nil {
+		return xerrors.Errorf("failed to write feed: %w", err)
+	}
+
+	return nil
+}
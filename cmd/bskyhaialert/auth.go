@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+	"golang.org/x/term"
+	"golang.org/x/xerrors"
+)
+
+const (
+	AUTH_PASSWORD = "password"
+	AUTH_OAUTH    = "oauth"
+
+	SESSION_STORE_FILE    = "file"
+	SESSION_STORE_KEYRING = "keyring"
+)
+
+// newClient logs in as the handle with the auth method of the config.
+func newClient(ctx context.Context, cfg *Config, handle, password, authFactorToken string) (*xrpc.Client, error) {
+	if err := setSessionStore(cfg); err != nil {
+		return nil, xerrors.Errorf("failed to set session store: %w", err)
+	}
+
+	if cfg.AuthMethod == AUTH_OAUTH {
+		client, err := bskystats.NewOAuthClient(ctx, strings.TrimPrefix(handle, "@"))
+		if err != nil {
+			return nil, xerrors.Errorf("failed to resume oauth session, run the login command: %w", err)
+		}
+
+		return client, nil
+	}
+
+	return newPasswordClient(ctx, cfg.Host, handle, password, authFactorToken)
+}
+
+// newPasswordClient logs in as the handle with the 2FA code.
+// If the account asks for a code and none was given, the emailed one is read from the terminal.
+func newPasswordClient(ctx context.Context, host, handle, password, authFactorToken string) (*xrpc.Client, error) {
+	client, err := bskystats.NewClientWithAuthFactorToken(ctx, host, handle, password, authFactorToken)
+	if err == nil || !errors.Is(err, bskystats.ErrAuthFactorTokenRequired) || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return client, err
+	}
+
+	token, err := prompt(bufio.NewReader(os.Stdin), fmt.Sprintf("Code emailed to %s: ", handle))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read code: %w", err)
+	}
+
+	return bskystats.NewClientWithAuthFactorToken(ctx, host, handle, password, token)
+}
+
+// oauthLogin authorizes the handle in a browser and saves the OAuth session.
+func oauthLogin(ctx context.Context, handle string) error {
+	return bskystats.OAuthLogin(ctx, strings.TrimPrefix(handle, "@"), func(url string) {
+		fmt.Printf("open this URL in a browser to log in as %s:\n%s\n", handle, url)
+	})
+}
+
+// renewSessions refreshes the sessions of the accounts, logging in again if the refresh tokens were revoked or expired.
+// The owner is alerted if that fails too. The result is reported by the health endpoints.
+func (a *App) renewSessions(ctx context.Context) (err error) {
+	defer func() { health.session(err) }()
+
+	// The OAuth sessions are refreshed by their clients as needed.
+	if a.cfg.AuthMethod == AUTH_OAUTH {
+		return nil
+	}
+
+	if err := bskystats.RenewSession(ctx, a.client, a.cfg.Password, a.cfg.AuthFactorToken); err != nil {
+		if a.ntfy != nil {
+			a.ntfy.alert(ctx, "再ログインに失敗しました", err)
+		}
+
+		return xerrors.Errorf("failed to renew session: %w", err)
+	}
+
+	if a.cfg.ReporterHandle != "" {
+		if err := bskystats.RenewSession(ctx, a.poster.client, a.cfg.ReporterPassword, ""); err != nil {
+			if a.ntfy != nil {
+				a.ntfy.alert(ctx, "再ログインに失敗しました", err)
+			}
+
+			return xerrors.Errorf("failed to renew reporter session: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// saveSessions saves the sessions of the accounts, so that they are resumed on the next start.
+func (a *App) saveSessions() error {
+	// The OAuth sessions are saved by their clients as they are refreshed.
+	if a.cfg.AuthMethod == AUTH_OAUTH {
+		return nil
+	}
+
+	if err := bskystats.SaveSession(a.client); err != nil {
+		return xerrors.Errorf("failed to save session: %w", err)
+	}
+
+	if a.cfg.ReporterHandle != "" {
+		if err := bskystats.SaveSession(a.poster.client); err != nil {
+			return xerrors.Errorf("failed to save reporter session: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// withSession runs f, and runs it once more after renewing the sessions if it failed for an expired or revoked session.
+func (a *App) withSession(ctx context.Context, f func() error) error {
+	err := f()
+	if !bskystats.IsAuthError(err) {
+		return err
+	}
+
+	slog.Warn("session expired, logging in again", "error", err)
+
+	if err := a.renewSessions(ctx); err != nil {
+		return xerrors.Errorf("failed to renew sessions: %w", err)
+	}
+
+	return f()
+}
+
+// setSessionStore points the clients to the session store of the config, encrypting the sessions if a passphrase is set.
+func setSessionStore(cfg *Config) error {
+	var store bskystats.SessionStore
+	switch cfg.SessionStore {
+	case SESSION_STORE_FILE:
+		store = bskystats.FileSessionStore{Dir: dataDir}
+	case SESSION_STORE_KEYRING:
+		store = bskystats.KeyringSessionStore{}
+	default:
+		return xerrors.Errorf("unknown session store: %s", cfg.SessionStore)
+	}
+
+	if cfg.SessionPassphrase != "" {
+		store = bskystats.EncryptedSessionStore{Store: store, Passphrase: cfg.SessionPassphrase}
+	}
+
+	if dryRun {
+		store = &dryRunSessionStore{store: store}
+	}
+
+	bskystats.Sessions = store
+
+	return nil
+}
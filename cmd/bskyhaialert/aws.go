@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsConfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"golang.org/x/xerrors"
+)
+
+// loadAwsSecrets overrides the keys of the config with the parameters under aws_ssm_path in Parameter Store,
+// and then with the ones of the JSON object in the secret aws_secret_id of Secrets Manager, if they are set.
+// The credentials are taken from the environment, such as the role of the ECS task or the Lambda function.
+func loadAwsSecrets(ctx context.Context, cfg *Config) error {
+	if cfg.AwsSsmPath == "" && cfg.AwsSecretId == "" {
+		return nil
+	}
+
+	var opts []func(*awsConfig.LoadOptions) error
+	if cfg.AwsRegion != "" {
+		opts = append(opts, awsConfig.WithRegion(cfg.AwsRegion))
+	}
+
+	awsCfg, err := awsConfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return xerrors.Errorf("failed to load aws config: %w", err)
+	}
+
+	if cfg.AwsSsmPath != "" {
+		params, err := getSsmParameters(ctx, ssm.NewFromConfig(awsCfg), cfg.AwsSsmPath)
+		if err != nil {
+			return xerrors.Errorf("failed to get parameters: %w", err)
+		}
+
+		if err := overrideConfig(ctx, cfg, "ssm", params); err != nil {
+			return xerrors.Errorf("failed to load parameters: %w", err)
+		}
+	}
+
+	if cfg.AwsSecretId != "" {
+		out, err := secretsmanager.NewFromConfig(awsCfg).GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+			SecretId: aws.String(cfg.AwsSecretId),
+		})
+		if err != nil {
+			return xerrors.Errorf("failed to get secret: %w", err)
+		}
+
+		var secrets map[string]any
+		if err := json.Unmarshal([]byte(aws.ToString(out.SecretString)), &secrets); err != nil {
+			return xerrors.Errorf("failed to parse secret: %w", err)
+		}
+
+		if err := overrideConfig(ctx, cfg, "secretsmanager", secrets); err != nil {
+			return xerrors.Errorf("failed to load secret: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// getSsmParameters returns the decrypted parameters under the path by their last segment, such as password for /bskyhaialert/password.
+func getSsmParameters(ctx context.Context, client *ssm.Client, ssmPath string) (map[string]any, error) {
+	params := map[string]any{}
+
+	paginator := ssm.NewGetParametersByPathPaginator(client, &ssm.GetParametersByPathInput{
+		Path:           aws.String(ssmPath),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get parameters by path: %w", err)
+		}
+
+		for _, p := range page.Parameters {
+			params[path.Base(aws.ToString(p.Name))] = aws.ToString(p.Value)
+		}
+	}
+
+	return params, nil
+}
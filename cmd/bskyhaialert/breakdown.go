@@ -0,0 +1,52 @@
+package main
+
+import (
+	"time"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+	"golang.org/x/xerrors"
+)
+
+// countPosts breaks down the feed items into original posts, replies and reposts.
+func countPosts(items []*bsky.FeedDefs_FeedViewPost) PostCounts {
+	var counts PostCounts
+	for _, item := range items {
+		switch {
+		case isRepost(item):
+			counts.Reposts++
+		case isReply(item.Post):
+			counts.Replies++
+		default:
+			counts.Originals++
+		}
+	}
+
+	return counts
+}
+
+// updatePostBreakdown records the breakdown of the feed items made on the day before today
+// and sets it to param along with the differences from the day before.
+func updatePostBreakdown(store *Store, today time.Time, items []*bsky.FeedDefs_FeedViewPost, param *Param) error {
+	yesterday := today.AddDate(0, 0, -1)
+
+	counts := countPosts(items)
+
+	if err := store.SavePostCounts(yesterday, counts); err != nil {
+		return xerrors.Errorf("failed to save post counts: %w", err)
+	}
+
+	prev, err := store.GetPostCounts(yesterday.AddDate(0, 0, -1))
+	if err != nil {
+		return xerrors.Errorf("failed to get post counts: %w", err)
+	}
+
+	param.PostBreakdown = true
+	param.OriginalsCount = counts.Originals
+	param.OriginalsCountDiff = counts.Originals - prev.Originals
+	param.RepliesCount = counts.Replies
+	param.RepliesCountDiff = counts.Replies - prev.Replies
+	param.RepostsCount = counts.Reposts
+	param.RepostsCountDiff = counts.Reposts - prev.Reposts
+
+	return nil
+}
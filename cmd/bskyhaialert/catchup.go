@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// isDailyRunMissed reports whether the daily job was due earlier on the day of now but has not run,
+// as when the process was down at the time. It is never missed before the job has run for the first time.
+func (a *App) isDailyRunMissed(now time.Time) (bool, error) {
+	at, err := time.ParseInLocation(POST_TIME_LAYOUT, a.cfg.PostTime, now.Location())
+	if err != nil {
+		return false, xerrors.Errorf("failed to parse post_time: %w", err)
+	}
+
+	due := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+	if now.Before(due) {
+		return false, nil
+	}
+
+	latest, err := a.store.GetLatestDailyRun()
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, xerrors.Errorf("failed to get latest daily run: %w", err)
+	}
+
+	return latest < now.Format(DATE_FORMAT), nil
+}
+
+// catchUp runs the daily job right away if its run of the day was missed.
+// The report covers every day since the latest stats recorded, as the job compares the stats with them,
+// and its Since and Days tell the span apart from a single day.
+func (a *App) catchUp(ctx context.Context, now time.Time) error {
+	missed, err := a.isDailyRunMissed(now)
+	if err != nil {
+		return xerrors.Errorf("failed to check missed run: %w", err)
+	}
+
+	if !missed {
+		return nil
+	}
+
+	slog.Info("the daily run was missed, running it now")
+
+	a.runDaily(ctx, now, false)
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"text/template"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+	chart "github.com/wcharczuk/go-chart/v2"
+	"golang.org/x/xerrors"
+)
+
+const (
+	CHART_DAYS       = 30
+	CHART_ALT_FORMAT = `{{ .From }}から{{ .To }}までのフォロワー数とポスト数の推移を示す折れ線グラフ。
+フォロワー数: {{ .FollowersFirst }}→{{ .FollowersLast }}({{ .FollowersTrend }}、最小{{ .FollowersMin }}、最大{{ .FollowersMax }})
+ポスト数: {{ .PostsFirst }}→{{ .PostsLast }}({{ .PostsTrend }}、最小{{ .PostsMin }}、最大{{ .PostsMax }})`
+)
+
+type ChartAltParam struct {
+	From           string
+	To             string
+	FollowersFirst int64
+	FollowersLast  int64
+	FollowersMin   int64
+	FollowersMax   int64
+	FollowersTrend string
+	PostsFirst     int64
+	PostsLast      int64
+	PostsMin       int64
+	PostsMax       int64
+	PostsTrend     string
+}
+
+// newChartEmbed renders the history of the last CHART_DAYS days before now as a chart
+// and uploads it as an image embed, described by the alt text template.
+func newChartEmbed(ctx context.Context, client *xrpc.Client, store *Store, altTmpl *template.Template, now time.Time) (*bsky.FeedPost_Embed, error) {
+	history, err := loadChartHistory(store, now)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load history: %w", err)
+	}
+
+	b, err := renderChart(history)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to render chart: %w", err)
+	}
+
+	alt, err := bskystats.RenderTemplate(altTmpl, newChartAltParam(history))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to render alt text: %w", err)
+	}
+
+	blob, err := atproto.RepoUploadBlob(ctx, client, bytes.NewReader(b))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to upload chart: %w", err)
+	}
+
+	return &bsky.FeedPost_Embed{
+		EmbedImages: &bsky.EmbedImages{
+			Images: []*bsky.EmbedImages_Image{
+				{
+					Alt:   alt,
+					Image: blob.Blob,
+				},
+			},
+		},
+	}, nil
+}
+
+// loadChartHistory returns the stats of the last CHART_DAYS days before now, of which there must be at least two.
+func loadChartHistory(store *Store, now time.Time) ([]Snapshot, error) {
+	history, err := store.GetStatsBetween(now.AddDate(0, 0, -CHART_DAYS), now)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get history: %w", err)
+	}
+
+	if len(history) < 2 {
+		return nil, xerrors.New("not enough history")
+	}
+
+	return history, nil
+}
+
+// renderHistoryChart renders the history of the last CHART_DAYS days before now as a PNG chart.
+func renderHistoryChart(store *Store, now time.Time) ([]byte, error) {
+	history, err := loadChartHistory(store, now)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load history: %w", err)
+	}
+
+	b, err := renderChart(history)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to render chart: %w", err)
+	}
+
+	return b, nil
+}
+
+// newChartAltParam describes the range, extremes and trend of the history.
+func newChartAltParam(history []Snapshot) *ChartAltParam {
+	first, last := history[0], history[len(history)-1]
+
+	param := &ChartAltParam{
+		From:           first.Date,
+		To:             last.Date,
+		FollowersFirst: first.Followers,
+		FollowersLast:  last.Followers,
+		FollowersMin:   first.Followers,
+		FollowersMax:   first.Followers,
+		FollowersTrend: trend(last.Followers - first.Followers),
+		PostsFirst:     first.Posts,
+		PostsLast:      last.Posts,
+		PostsMin:       first.Posts,
+		PostsMax:       first.Posts,
+		PostsTrend:     trend(last.Posts - first.Posts),
+	}
+
+	for _, snapshot := range history {
+		if snapshot.Followers < param.FollowersMin {
+			param.FollowersMin = snapshot.Followers
+		}
+
+		if snapshot.Followers > param.FollowersMax {
+			param.FollowersMax = snapshot.Followers
+		}
+
+		if snapshot.Posts < param.PostsMin {
+			param.PostsMin = snapshot.Posts
+		}
+
+		if snapshot.Posts > param.PostsMax {
+			param.PostsMax = snapshot.Posts
+		}
+	}
+
+	return param
+}
+
+func trend(diff int64) string {
+	if diff > 0 {
+		return "増加"
+	}
+
+	if diff < 0 {
+		return "減少"
+	}
+
+	return "横ばい"
+}
+
+// renderChart draws followers and posts of the history as a PNG line chart.
+func renderChart(history []Snapshot) ([]byte, error) {
+	dates := make([]time.Time, 0, len(history))
+	followers := make([]float64, 0, len(history))
+	posts := make([]float64, 0, len(history))
+
+	for _, snapshot := range history {
+		date, err := time.Parse(DATE_FORMAT, snapshot.Date)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse date: %w", err)
+		}
+
+		dates = append(dates, date)
+		followers = append(followers, float64(snapshot.Followers))
+		posts = append(posts, float64(snapshot.Posts))
+	}
+
+	graph := chart.Chart{
+		Width:  1000,
+		Height: 500,
+		XAxis: chart.XAxis{
+			ValueFormatter: chart.TimeDateValueFormatter,
+		},
+		YAxis: chart.YAxis{
+			Name: "Followers",
+		},
+		YAxisSecondary: chart.YAxis{
+			Name: "Posts",
+		},
+		Series: []chart.Series{
+			chart.TimeSeries{
+				Name:    "Followers",
+				XValues: dates,
+				YValues: followers,
+			},
+			chart.TimeSeries{
+				Name:    "Posts",
+				YAxis:   chart.YAxisSecondary,
+				XValues: dates,
+				YValues: posts,
+			},
+		},
+	}
+
+	graph.Elements = []chart.Renderable{
+		chart.Legend(&graph),
+	}
+
+	buf := new(bytes.Buffer)
+
+	if err := graph.Render(chart.PNG, buf); err != nil {
+		return nil, xerrors.Errorf("failed to render png: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
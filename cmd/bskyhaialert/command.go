@@ -0,0 +1,539 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/go-co-op/gocron"
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// HISTORY_DAYS is the default number of days the history command prints.
+	HISTORY_DAYS = 30
+
+	// CONFIG_WATCH_INTERVAL is how often the run command checks the config file for changes.
+	CONFIG_WATCH_INTERVAL = 10 * time.Second
+
+	// POST_TIME is the default time of the day the daily job runs at, in POST_TIME_LAYOUT.
+	POST_TIME        = "00:00"
+	POST_TIME_LAYOUT = "15:04"
+)
+
+// runCommand runs the daily job and the hourly checks on schedule until the process is stopped.
+// The config is reloaded on SIGHUP or when its file changes.
+// On SIGTERM or SIGINT, the running jobs are finished and the sessions are saved before it returns.
+// SIGUSR1 runs the daily job right away even if it has already run today, and SIGUSR2 logs its post instead.
+// With -once, the daily job is run right away instead and the command exits, leaving the schedule to cron and the like.
+func runCommand(args []string) error {
+	fs, path := newFlagSet("run")
+	once := fs.Bool("once", false, "run the daily job once and exit instead of scheduling it")
+	fs.Parse(args)
+
+	if err := prepareDataDir(); err != nil {
+		return xerrors.Errorf("failed to prepare data dir: %w", err)
+	}
+
+	ctx := context.Background()
+
+	cfg, err := loadConfig(ctx, *path)
+	if err != nil {
+		return xerrors.Errorf("failed to load config: %w", err)
+	}
+
+	if err := setupLogging(cfg); err != nil {
+		return xerrors.Errorf("failed to set up logging: %w", err)
+	}
+
+	shutdownTracing, err := setupTracing(ctx, cfg)
+	if err != nil {
+		return xerrors.Errorf("failed to set up tracing: %w", err)
+	}
+
+	defer shutdownTracing(ctx)
+
+	app, err := newApp(ctx, cfg)
+	if err != nil {
+		return xerrors.Errorf("failed to set up: %w", err)
+	}
+
+	defer func() { app.Close() }()
+
+	health.session(nil)
+
+	if err := app.initStats(ctx, time.Now()); err != nil {
+		return xerrors.Errorf("failed to initialize stats: %w", err)
+	}
+
+	if *once {
+		app.runDaily(ctx, time.Now(), false)
+		return nil
+	}
+
+	if cfg.HealthAddr != "" {
+		if err := serveHealth(cfg.HealthAddr); err != nil {
+			return xerrors.Errorf("failed to serve health: %w", err)
+		}
+	}
+
+	if err := app.catchUp(ctx, time.Now()); err != nil {
+		slog.Error("failed to catch up", "error", err)
+	}
+
+	// lastAlert is the Unix time of the last mention spike alert, kept across the reloads not to alert twice a day.
+	// It is atomic, as the job of the previous scheduler may still be running after a reload.
+	var lastAlert atomic.Int64
+
+	s := newScheduler(ctx, app, &lastAlert)
+
+	slog.Info("Starting...")
+	s.StartAsync()
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, os.Interrupt)
+
+	usr := make(chan os.Signal, 1)
+	signal.Notify(usr, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	changed := make(chan struct{}, 1)
+	go watchConfig(findConfig(*path), changed)
+
+	// running tracks the jobs run by the signals, which are waited for like the scheduled ones.
+	var running sync.WaitGroup
+
+	// The config is reloaded on SIGHUP or when the file changes, keeping the current one if the new one fails.
+	for {
+		select {
+		case <-hup:
+		case <-changed:
+		case sig := <-usr:
+			app := app
+			running.Go(func() { runNow(ctx, app, sig == syscall.SIGUSR2) })
+			continue
+		case sig := <-stop:
+			slog.Info("shutting down", "signal", sig.String())
+
+			// Stop waits for the running jobs, so that a report being delivered is not cut off.
+			// The stats are recorded by the jobs as they run, and are flushed as the store is closed on return.
+			s.Stop()
+			running.Wait()
+
+			if err := app.saveSessions(); err != nil {
+				slog.Error("failed to save sessions", "error", err)
+			}
+
+			slog.Info("stopped")
+
+			return nil
+		}
+
+		slog.Info("reloading config")
+
+		next, err := reloadApp(ctx, *path)
+		if err != nil {
+			slog.Error("failed to reload config", "error", err)
+			continue
+		}
+
+		s.Stop()
+		running.Wait()
+		app.Close()
+
+		app = next
+		s = newScheduler(ctx, app, &lastAlert)
+		s.StartAsync()
+
+		slog.Info("config reloaded")
+	}
+}
+
+// runNow runs the daily job of the app outside the schedule, or only logs its post if preview is true.
+// The report is delivered again, but the periodic posts such as the weekly summary are only made if they are still due.
+// It is run in the background, and runDaily waits for the scheduled job if it is running.
+func runNow(ctx context.Context, app *App, preview bool) {
+	if err := app.renewSessions(ctx); err != nil {
+		slog.Error("failed to renew sessions", "error", err)
+		return
+	}
+
+	if !preview {
+		slog.Info("running the daily job now")
+		app.runDaily(ctx, time.Now(), true)
+		return
+	}
+
+	text, err := app.previewDaily(ctx, time.Now())
+	if err != nil {
+		slog.Error("failed to preview daily post", "error", err)
+		return
+	}
+
+	slog.Info("daily post preview", "text", text)
+}
+
+// newScheduler schedules the daily job, the schedules of the config, the interval posts and the hourly checks of the app.
+func newScheduler(ctx context.Context, app *App, lastAlert *atomic.Int64) *gocron.Scheduler {
+	s := gocron.NewScheduler(time.Local)
+
+	s.Every(HEALTH_BEAT_INTERVAL).Do(func() {
+		health.beat(time.Now())
+	})
+
+	// The sessions are renewed at the start of every run, as the access tokens acquired at startup expire in hours.
+	s.Every(1).Day().At(app.cfg.PostTime).Do(func() {
+		if err := app.renewSessions(ctx); err != nil {
+			slog.Error("failed to renew sessions", "error", err)
+			return
+		}
+
+		app.runDaily(ctx, time.Now(), false)
+	})
+
+	for i, schedule := range app.cfg.Schedules {
+		job := s.Every(1).Day()
+		if schedule.Weekday != "" {
+			weekday, _ := parseWeekday(schedule.Weekday)
+			job = s.Every(1).Week().Weekday(weekday)
+		}
+
+		job.At(schedule.At).Do(func() {
+			if err := app.renewSessions(ctx); err != nil {
+				slog.Error("failed to renew sessions", "error", err)
+				return
+			}
+
+			if err := app.runSchedule(ctx, schedule, app.schedules[i], time.Now()); err != nil {
+				slog.Error("failed to run schedule", "schedule", schedule.Name, "error", err)
+				return
+			}
+
+			slog.Info("schedule post success", "schedule", schedule.Name)
+		})
+	}
+
+	if app.cfg.IntervalHours > 0 {
+		s.Every(app.cfg.IntervalHours).Hours().Do(func() {
+			if err := app.renewSessions(ctx); err != nil {
+				slog.Error("failed to renew sessions", "error", err)
+				return
+			}
+
+			if err := app.runInterval(ctx, app.interval, time.Now()); err != nil {
+				slog.Error("failed to run interval", "error", err)
+				return
+			}
+
+			slog.Info("interval post success")
+		})
+	}
+
+	if app.cfg.MentionSpike > 0 {
+		// The checks run one at a time, so that a slow one does not overlap the next and alert twice.
+		s.Every(1).Hour().SingletonMode().Do(func() {
+			now := time.Now()
+			if now.Sub(time.Unix(lastAlert.Load(), 0)) < 24*time.Hour {
+				return
+			}
+
+			if err := app.renewSessions(ctx); err != nil {
+				slog.Error("failed to renew sessions", "error", err)
+				return
+			}
+
+			alerted, err := app.checkMentionSpike(ctx, now)
+			if err != nil {
+				slog.Error("failed to check mention spike", "error", err)
+				return
+			}
+
+			if alerted {
+				lastAlert.Store(now.Unix())
+				slog.Info("mention spike alert success")
+			}
+		})
+	}
+
+	return s
+}
+
+// reloadApp sets up a new app from the config at path.
+func reloadApp(ctx context.Context, path string) (*App, error) {
+	cfg, err := loadConfig(ctx, path)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load config: %w", err)
+	}
+
+	if err := setupLogging(cfg); err != nil {
+		return nil, xerrors.Errorf("failed to set up logging: %w", err)
+	}
+
+	app, err := newApp(ctx, cfg)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to set up: %w", err)
+	}
+
+	return app, nil
+}
+
+// watchConfig notifies changed when the modification time of the file at path changes.
+func watchConfig(path string, changed chan<- struct{}) {
+	var modTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime()
+	}
+
+	for range time.Tick(CONFIG_WATCH_INTERVAL) {
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Equal(modTime) {
+			continue
+		}
+
+		modTime = info.ModTime()
+
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// postNowCommand runs the daily job once outside the schedule, reporting the change
+// since the latest stats recorded before today, unless the job has already run today and -force is not given.
+func postNowCommand(args []string) error {
+	fs, path := newFlagSet("post-now")
+	force := fs.Bool("force", false, "run the daily job even if it has already run today")
+	fs.Parse(args)
+
+	if err := prepareDataDir(); err != nil {
+		return xerrors.Errorf("failed to prepare data dir: %w", err)
+	}
+
+	ctx := context.Background()
+
+	cfg, err := loadConfig(ctx, *path)
+	if err != nil {
+		return xerrors.Errorf("failed to load config: %w", err)
+	}
+
+	if err := setupLogging(cfg); err != nil {
+		return xerrors.Errorf("failed to set up logging: %w", err)
+	}
+
+	shutdownTracing, err := setupTracing(ctx, cfg)
+	if err != nil {
+		return xerrors.Errorf("failed to set up tracing: %w", err)
+	}
+
+	defer shutdownTracing(ctx)
+
+	app, err := newApp(ctx, cfg)
+	if err != nil {
+		return xerrors.Errorf("failed to set up: %w", err)
+	}
+
+	defer app.Close()
+
+	app.runDaily(ctx, time.Now(), *force)
+
+	return nil
+}
+
+// fetchCommand prints the current stats of the account as JSON without recording them.
+func fetchCommand(args []string) error {
+	fs, path := newFlagSet("fetch")
+	fs.Parse(args)
+
+	if err := prepareDataDir(); err != nil {
+		return xerrors.Errorf("failed to prepare data dir: %w", err)
+	}
+
+	ctx := context.Background()
+
+	cfg, err := loadConfig(ctx, *path)
+	if err != nil {
+		return xerrors.Errorf("failed to load config: %w", err)
+	}
+
+	client, err := newClient(ctx, cfg, cfg.Handle, cfg.Password, cfg.AuthFactorToken)
+	if err != nil {
+		return xerrors.Errorf("failed to create client: %w", err)
+	}
+
+	data, err := bskystats.FetchData(ctx, client)
+	if err != nil {
+		return xerrors.Errorf("failed to fetch data: %w", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+
+	return enc.Encode(data)
+}
+
+// previewCommand prints the daily post rendered from the current stats and the latest ones recorded before today,
+// without recording or posting anything.
+func previewCommand(args []string) error {
+	fs, path := newFlagSet("preview")
+	fs.Parse(args)
+
+	if err := prepareDataDir(); err != nil {
+		return xerrors.Errorf("failed to prepare data dir: %w", err)
+	}
+
+	ctx := context.Background()
+
+	cfg, err := loadConfig(ctx, *path)
+	if err != nil {
+		return xerrors.Errorf("failed to load config: %w", err)
+	}
+
+	app, err := newApp(ctx, cfg)
+	if err != nil {
+		return xerrors.Errorf("failed to set up: %w", err)
+	}
+
+	defer app.Close()
+
+	text, err := app.previewDaily(ctx, time.Now())
+	if err != nil {
+		return xerrors.Errorf("failed to preview daily post: %w", err)
+	}
+
+	fmt.Println(text)
+
+	return nil
+}
+
+// historyCommand prints the stats recorded in the last days, oldest first.
+func historyCommand(args []string) error {
+	fs, path := newFlagSet("history")
+	days := fs.Int("days", HISTORY_DAYS, "number of days to print")
+	fs.Parse(args)
+
+	if err := prepareDataDir(); err != nil {
+		return xerrors.Errorf("failed to prepare data dir: %w", err)
+	}
+
+	// The config is loaded for its timezone, so that the days are the same as those of the run and preview commands.
+	if _, err := loadConfig(context.Background(), *path); err != nil {
+		return xerrors.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := bskystats.OpenStore(statePath(STATS_DB))
+	if err != nil {
+		return xerrors.Errorf("failed to open store: %w", err)
+	}
+
+	defer store.Close()
+
+	now := time.Now()
+
+	snapshots, err := store.GetStatsBetween(now.AddDate(0, 0, -*days), now)
+	if err != nil {
+		return xerrors.Errorf("failed to get stats: %w", err)
+	}
+
+	fmt.Println("date\tposts\tfollows\tfollowers")
+	for _, snapshot := range snapshots {
+		fmt.Printf("%s\t%d\t%d\t%d\n", snapshot.Date, snapshot.Posts, snapshot.Follows, snapshot.Followers)
+	}
+
+	return nil
+}
+
+// validateConfigCommand checks the config and prints all the problems found in it.
+func validateConfigCommand(args []string) error {
+	fs, path := newFlagSet("validate-config")
+	fs.Parse(args)
+
+	ctx := context.Background()
+
+	// The problems of the secrets and the timezone are listed with the others instead of failing the load.
+	cfg, err := loadRawConfig(ctx, *path)
+	if err != nil {
+		return xerrors.Errorf("failed to load config: %w", err)
+	}
+
+	var errs []error
+	if err := resolveConfig(ctx, cfg); err != nil {
+		errs = append(errs, unjoin(err)...)
+	}
+
+	errs = append(errs, validateConfig(ctx, cfg)...)
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Printf("- %v\n", err)
+		}
+
+		return xerrors.Errorf("%d problems found", len(errs))
+	}
+
+	fmt.Println("config is valid")
+
+	return nil
+}
+
+// loginCommand logs in as the account and the reporter, if any, saving their sessions.
+// With OAuth, each account is authorized in a browser first.
+// It does nothing but save the sessions, so -dry-run is rejected.
+func loginCommand(args []string) error {
+	fs, path := newFlagSet("login")
+	fs.Parse(args)
+
+	if dryRun {
+		return xerrors.New("login does not support -dry-run")
+	}
+
+	if err := prepareDataDir(); err != nil {
+		return xerrors.Errorf("failed to prepare data dir: %w", err)
+	}
+
+	ctx := context.Background()
+
+	cfg, err := loadConfig(ctx, *path)
+	if err != nil {
+		return xerrors.Errorf("failed to load config: %w", err)
+	}
+
+	if cfg.AuthMethod == AUTH_OAUTH {
+		if err := oauthLogin(ctx, cfg.Handle); err != nil {
+			return xerrors.Errorf("failed to log in with oauth: %w", err)
+		}
+	}
+
+	client, err := newClient(ctx, cfg, cfg.Handle, cfg.Password, cfg.AuthFactorToken)
+	if err != nil {
+		return xerrors.Errorf("failed to create client: %w", err)
+	}
+
+	fmt.Printf("logged in as %s (%s)\n", client.Auth.Handle, client.Auth.Did)
+
+	if cfg.ReporterHandle != "" {
+		if cfg.AuthMethod == AUTH_OAUTH {
+			if err := oauthLogin(ctx, cfg.ReporterHandle); err != nil {
+				return xerrors.Errorf("failed to log in reporter with oauth: %w", err)
+			}
+		}
+
+		reporter, err := newClient(ctx, cfg, cfg.ReporterHandle, cfg.ReporterPassword, "")
+		if err != nil {
+			return xerrors.Errorf("failed to create reporter client: %w", err)
+		}
+
+		fmt.Printf("logged in as %s (%s)\n", reporter.Auth.Handle, reporter.Auth.Did)
+	}
+
+	return nil
+}
@@ -0,0 +1,540 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/BurntSushi/toml"
+	"github.com/heetch/confita"
+	"github.com/heetch/confita/backend"
+	confitaEnv "github.com/heetch/confita/backend/env"
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v3"
+)
+
+// CONFIG_EXTS are the extensions of the config formats.
+var CONFIG_EXTS = []string{".json", ".yaml", ".yml", ".toml"}
+
+const (
+	// CONFIG_PATH is the default path of the config file.
+	// If it doesn't exist, the same file in the other formats is looked for.
+	CONFIG_PATH = "config.json"
+
+	// CONFIG_ENV_PREFIX is prepended to the keys of the config to look them up in the environment, such as BSKY_HANDLE.
+	CONFIG_ENV_PREFIX = "BSKY_"
+)
+
+type Config struct {
+	Host     string `config:"host"`
+	Handle   string `config:"handle"`
+	Password string `config:"password"`
+
+	// AuthFactorToken is the code emailed to the account when email 2FA is enabled.
+	// It's only needed when a new session is created, and is asked for on a terminal when empty.
+	AuthFactorToken string `config:"auth_factor_token" json:"auth_factor_token"`
+
+	// AuthMethod is how the accounts log in, with the password or with the OAuth session saved by the login command.
+	AuthMethod string `config:"auth_method" json:"auth_method"`
+
+	// SessionStore is where the sessions are kept, in files in the working directory or in the keyring of the OS.
+	SessionStore string `config:"session_store" json:"session_store"`
+
+	// SessionPassphrase encrypts the sessions at rest if set.
+	SessionPassphrase string `config:"session_passphrase" json:"session_passphrase"`
+
+	// ReporterHandle and ReporterPassword are the credentials of the account that publishes the posts.
+	// If ReporterHandle is empty, the posts are published from the account whose stats are collected.
+	ReporterHandle   string `config:"reporter_handle" json:"reporter_handle"`
+	ReporterPassword string `config:"reporter_password" json:"reporter_password"`
+
+	TemplatePath     string            `config:"template_path" json:"template_path"`
+	WeekdayTemplates map[string]string `config:"weekday_templates" json:"weekday_templates"`
+
+	// Timezone is the IANA name of the time zone the days are counted and scheduled in, such as Asia/Tokyo.
+	// The local time zone of the host is used if empty.
+	Timezone string `config:"timezone"`
+
+	// PostTime is the time of the day the daily job runs at, such as 09:00.
+	PostTime string `config:"post_time" json:"post_time"`
+
+	// Schedules are the reports posted on schedules of their own besides the daily one.
+	Schedules []Schedule `config:"schedules"`
+
+	// IntervalHours enables posting the change since the previous interval every so many hours, besides the daily post.
+	IntervalHours    int    `config:"interval_hours" json:"interval_hours"`
+	IntervalTemplate string `config:"interval_template" json:"interval_template"`
+
+	// DuplicateCheck looks for the daily post in the recent posts of the account before posting it,
+	// in case it was posted without being recorded, telling it by the DuplicateMarker template in its text.
+	DuplicateCheck  bool   `config:"duplicate_check" json:"duplicate_check"`
+	DuplicateMarker string `config:"duplicate_marker" json:"duplicate_marker"`
+
+	// HealthAddr is the address the health endpoints are served on by the run command, such as :8080.
+	// They are not served if empty, and a change takes effect on restart.
+	HealthAddr string `config:"health_addr" json:"health_addr"`
+
+	// OtlpEndpoint enables exporting the traces of the runs to the OTLP/HTTP collector at the address, such as localhost:4318.
+	// A change takes effect on restart.
+	OtlpEndpoint string `config:"otlp_endpoint" json:"otlp_endpoint"`
+	OtlpInsecure bool   `config:"otlp_insecure" json:"otlp_insecure"`
+
+	// LogFormat is the format of the logs, text or json, and LogLevel is the lowest level logged, such as debug.
+	LogFormat string `config:"log_format" json:"log_format"`
+	LogLevel  string `config:"log_level" json:"log_level"`
+
+	// LogFile writes the logs to the file instead of stderr, rotating it once it grows over LogMaxSize megabytes.
+	// The rotated files older than LogMaxAge days or beyond the newest LogMaxBackups are removed, unless those are zero.
+	LogFile       string `config:"log_file" json:"log_file"`
+	LogMaxSize    int    `config:"log_max_size" json:"log_max_size"`
+	LogMaxAge     int    `config:"log_max_age" json:"log_max_age"`
+	LogMaxBackups int    `config:"log_max_backups" json:"log_max_backups"`
+
+	SkipUnchanged bool    `config:"skip_unchanged" json:"skip_unchanged"`
+	MinChange     int64   `config:"min_change" json:"min_change"`
+	MinChangeRate float64 `config:"min_change_rate" json:"min_change_rate"`
+
+	Thread           bool   `config:"thread"`
+	HeadlineTemplate string `config:"headline_template" json:"headline_template"`
+
+	Chain bool `config:"chain"`
+	Pin   bool `config:"pin"`
+
+	Engagement bool `config:"engagement"`
+	Activity   bool `config:"activity"`
+	LikesGiven bool `config:"likes_given" json:"likes_given"`
+
+	PostBreakdown bool `config:"post_breakdown" json:"post_breakdown"`
+
+	FollowerSnapshot bool `config:"follower_snapshot" json:"follower_snapshot"`
+	ShowUnfollowers  bool `config:"show_unfollowers" json:"show_unfollowers"`
+	ChurnReport      bool `config:"churn_report" json:"churn_report"`
+	Mutuals          bool `config:"mutuals"`
+	NonFollowback    bool `config:"non_followback" json:"non_followback"`
+
+	Watchlist        []string `config:"watchlist"`
+	WatchlistCompare bool     `config:"watchlist_compare" json:"watchlist_compare"`
+
+	InactiveFollows bool `config:"inactive_follows" json:"inactive_follows"`
+	InactiveDays    int  `config:"inactive_days" json:"inactive_days"`
+
+	RatioPrecision int `config:"ratio_precision" json:"ratio_precision"`
+
+	QuoteTopPost     bool   `config:"quote_top_post" json:"quote_top_post"`
+	TopPostsReply    bool   `config:"top_posts_reply" json:"top_posts_reply"`
+	TopPostsTemplate string `config:"top_posts_template" json:"top_posts_template"`
+
+	ThankFollowers         bool   `config:"thank_followers" json:"thank_followers"`
+	ThankFollowersLimit    int    `config:"thank_followers_limit" json:"thank_followers_limit"`
+	ThankFollowersTemplate string `config:"thank_followers_template" json:"thank_followers_template"`
+
+	Weekly       bool   `config:"weekly"`
+	WeeklyFormat string `config:"weekly_format" json:"weekly_format"`
+
+	Leaderboard         bool     `config:"leaderboard"`
+	LeaderboardOptOut   []string `config:"leaderboard_opt_out" json:"leaderboard_opt_out"`
+	LeaderboardTemplate string   `config:"leaderboard_template" json:"leaderboard_template"`
+
+	Monthly         bool   `config:"monthly"`
+	MonthlyTemplate string `config:"monthly_template" json:"monthly_template"`
+
+	Yearly       bool   `config:"yearly"`
+	YearlyFormat string `config:"yearly_format" json:"yearly_format"`
+
+	Milestones        []int64 `config:"milestones"`
+	MilestoneTemplate string  `config:"milestone_template" json:"milestone_template"`
+
+	Chart            bool   `config:"chart"`
+	ChartAltTemplate string `config:"chart_alt_template" json:"chart_alt_template"`
+
+	MentionSpike         float64 `config:"mention_spike" json:"mention_spike"`
+	MentionSpikeMin      int64   `config:"mention_spike_min" json:"mention_spike_min"`
+	MentionSpikeTemplate string  `config:"mention_spike_template" json:"mention_spike_template"`
+	// MentionSpikeDm sends the alert to DmRecipient as a direct message instead of posting it.
+	MentionSpikeDm bool `config:"mention_spike_dm" json:"mention_spike_dm"`
+
+	Delivery    string `config:"delivery"`
+	DmRecipient string `config:"dm_recipient" json:"dm_recipient"`
+
+	DiscordWebhook string `config:"discord_webhook" json:"discord_webhook"`
+	SlackWebhook   string `config:"slack_webhook" json:"slack_webhook"`
+
+	TelegramBotToken string `config:"telegram_bot_token" json:"telegram_bot_token"`
+	TelegramChatId   string `config:"telegram_chat_id" json:"telegram_chat_id"`
+	TelegramChart    bool   `config:"telegram_chart" json:"telegram_chart"`
+
+	SmtpHost     string   `config:"smtp_host" json:"smtp_host"`
+	SmtpPort     int      `config:"smtp_port" json:"smtp_port"`
+	SmtpTls      string   `config:"smtp_tls" json:"smtp_tls"`
+	SmtpUsername string   `config:"smtp_username" json:"smtp_username"`
+	SmtpPassword string   `config:"smtp_password" json:"smtp_password"`
+	SmtpFrom     string   `config:"smtp_from" json:"smtp_from"`
+	SmtpTo       []string `config:"smtp_to" json:"smtp_to"`
+	SmtpHtml     bool     `config:"smtp_html" json:"smtp_html"`
+	SmtpWeekly   bool     `config:"smtp_weekly" json:"smtp_weekly"`
+
+	Webhooks      []string `config:"webhooks"`
+	WebhookSecret string   `config:"webhook_secret" json:"webhook_secret"`
+
+	NtfyUrl   string `config:"ntfy_url" json:"ntfy_url"`
+	NtfyToken string `config:"ntfy_token" json:"ntfy_token"`
+
+	MatrixHomeserver  string `config:"matrix_homeserver" json:"matrix_homeserver"`
+	MatrixAccessToken string `config:"matrix_access_token" json:"matrix_access_token"`
+	MatrixRoomId      string `config:"matrix_room_id" json:"matrix_room_id"`
+
+	MastodonUrl         string `config:"mastodon_url" json:"mastodon_url"`
+	MastodonAccessToken string `config:"mastodon_access_token" json:"mastodon_access_token"`
+	MastodonVisibility  string `config:"mastodon_visibility" json:"mastodon_visibility"`
+	MastodonTemplate    string `config:"mastodon_template" json:"mastodon_template"`
+
+	MisskeyUrl        string `config:"misskey_url" json:"misskey_url"`
+	MisskeyToken      string `config:"misskey_token" json:"misskey_token"`
+	MisskeyVisibility string `config:"misskey_visibility" json:"misskey_visibility"`
+
+	// NostrPrivateKey signs the notes published to NostrRelays, encoded in hex or as an nsec.
+	NostrRelays     []string `config:"nostr_relays" json:"nostr_relays"`
+	NostrPrivateKey string   `config:"nostr_private_key" json:"nostr_private_key"`
+
+	XConsumerKey       string `config:"x_consumer_key" json:"x_consumer_key"`
+	XConsumerSecret    string `config:"x_consumer_secret" json:"x_consumer_secret"`
+	XAccessToken       string `config:"x_access_token" json:"x_access_token"`
+	XAccessTokenSecret string `config:"x_access_token_secret" json:"x_access_token_secret"`
+	XTemplate          string `config:"x_template" json:"x_template"`
+
+	// Atom writes the Atom feed of the daily reports to AtomPath, relative to the data dir, linking AtomUrl as its location if any.
+	Atom     bool   `config:"atom"`
+	AtomPath string `config:"atom_path" json:"atom_path"`
+	AtomUrl  string `config:"atom_url" json:"atom_url"`
+
+	MqttBroker      string `config:"mqtt_broker" json:"mqtt_broker"`
+	MqttClientId    string `config:"mqtt_client_id" json:"mqtt_client_id"`
+	MqttUsername    string `config:"mqtt_username" json:"mqtt_username"`
+	MqttPassword    string `config:"mqtt_password" json:"mqtt_password"`
+	MqttTopicPrefix string `config:"mqtt_topic_prefix" json:"mqtt_topic_prefix"`
+
+	// HomeAssistant announces the metrics to Home Assistant through MQTT discovery under HomeAssistantPrefix.
+	HomeAssistant       bool   `config:"home_assistant" json:"home_assistant"`
+	HomeAssistantPrefix string `config:"home_assistant_prefix" json:"home_assistant_prefix"`
+
+	// Formats selects the format of the report by the name of the sink, such as {"slack": "markdown"}.
+	// The sinks not listed there get the text of the template.
+	Formats map[string]string `config:"formats"`
+
+	// SinkRetries is the number of times the delivery to a sink is retried when it fails.
+	SinkRetries int `config:"sink_retries" json:"sink_retries"`
+
+	Langs         []string `config:"langs"`
+	Threadgate    string   `config:"threadgate"`
+	DisableQuotes bool     `config:"disable_quotes" json:"disable_quotes"`
+
+	// VaultAddr enables reading the secrets from the secret at VaultPath on the Vault server, logged in with VaultAuth.
+	VaultAddr     string `config:"vault_addr" json:"vault_addr"`
+	VaultAuth     string `config:"vault_auth" json:"vault_auth"`
+	VaultToken    string `config:"vault_token" json:"vault_token"`
+	VaultRoleId   string `config:"vault_role_id" json:"vault_role_id"`
+	VaultSecretId string `config:"vault_secret_id" json:"vault_secret_id"`
+	VaultRole     string `config:"vault_role" json:"vault_role"`
+	VaultPath     string `config:"vault_path" json:"vault_path"`
+
+	// AwsSsmPath and AwsSecretId enable reading the config from AWS Parameter Store and Secrets Manager in AwsRegion,
+	// or the region of the environment if empty.
+	AwsRegion   string `config:"aws_region" json:"aws_region"`
+	AwsSsmPath  string `config:"aws_ssm_path" json:"aws_ssm_path"`
+	AwsSecretId string `config:"aws_secret_id" json:"aws_secret_id"`
+
+	// The *File options read the secret of the same name from the file, such as a Docker or Kubernetes secret.
+	// They take precedence over the secrets themselves.
+	PasswordFile            string `config:"password_file" json:"password_file"`
+	ReporterPasswordFile    string `config:"reporter_password_file" json:"reporter_password_file"`
+	SessionPassphraseFile   string `config:"session_passphrase_file" json:"session_passphrase_file"`
+	TelegramBotTokenFile    string `config:"telegram_bot_token_file" json:"telegram_bot_token_file"`
+	SmtpPasswordFile        string `config:"smtp_password_file" json:"smtp_password_file"`
+	WebhookSecretFile       string `config:"webhook_secret_file" json:"webhook_secret_file"`
+	NtfyTokenFile           string `config:"ntfy_token_file" json:"ntfy_token_file"`
+	MatrixAccessTokenFile   string `config:"matrix_access_token_file" json:"matrix_access_token_file"`
+	MastodonAccessTokenFile string `config:"mastodon_access_token_file" json:"mastodon_access_token_file"`
+	MisskeyTokenFile        string `config:"misskey_token_file" json:"misskey_token_file"`
+	NostrPrivateKeyFile     string `config:"nostr_private_key_file" json:"nostr_private_key_file"`
+	XConsumerSecretFile     string `config:"x_consumer_secret_file" json:"x_consumer_secret_file"`
+	XAccessTokenSecretFile  string `config:"x_access_token_secret_file" json:"x_access_token_secret_file"`
+	MqttPasswordFile        string `config:"mqtt_password_file" json:"mqtt_password_file"`
+}
+
+// loadConfig loads the config from the environment, the file at path, if any, and then the flags on top of the defaults,
+// and resolves the secrets and the timezone it refers to.
+func loadConfig(ctx context.Context, path string) (*Config, error) {
+	cfg, err := loadRawConfig(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := resolveConfig(ctx, cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadRawConfig loads the config like loadConfig without resolving anything it refers to.
+func loadRawConfig(ctx context.Context, path string) (*Config, error) {
+	loader := confita.NewLoader(
+		newEnvBackend(),
+		&configFileBackend{path: findConfig(path)},
+	)
+
+	cfg := &Config{
+		AuthMethod:             AUTH_PASSWORD,
+		PostTime:               POST_TIME,
+		SessionStore:           SESSION_STORE_FILE,
+		HeadlineTemplate:       HEADLINE_POST_FORMAT,
+		TopPostsTemplate:       TOP_POSTS_FORMAT,
+		IntervalTemplate:       INTERVAL_POST_FORMAT,
+		DuplicateMarker:        DUPLICATE_MARKER_FORMAT,
+		LogFormat:              LOG_FORMAT_TEXT,
+		LogLevel:               LOG_LEVEL,
+		LogMaxSize:             LOG_MAX_SIZE,
+		ThankFollowersLimit:    THANK_FOLLOWERS_LIMIT,
+		ThankFollowersTemplate: THANK_FOLLOWERS_FORMAT,
+		LeaderboardTemplate:    LEADERBOARD_FORMAT,
+		WeeklyFormat:           WEEKLY_POST_FORMAT,
+		MonthlyTemplate:        MONTHLY_POST_FORMAT,
+		YearlyFormat:           YEARLY_POST_FORMAT,
+		MilestoneTemplate:      MILESTONE_POST_FORMAT,
+		ChartAltTemplate:       CHART_ALT_FORMAT,
+		RatioPrecision:         RATIO_PRECISION,
+		InactiveDays:           INACTIVE_DAYS,
+		MentionSpikeMin:        MENTION_SPIKE_MIN,
+		MentionSpikeTemplate:   MENTION_SPIKE_FORMAT,
+		Delivery:               DELIVERY_POST,
+		SmtpPort:               SMTP_PORT,
+		SmtpTls:                SMTP_TLS_STARTTLS,
+		MastodonVisibility:     MASTODON_VISIBILITY,
+		MastodonTemplate:       MASTODON_POST_FORMAT,
+		MisskeyVisibility:      MISSKEY_VISIBILITY,
+		XTemplate:              X_POST_FORMAT,
+		AtomPath:               ATOM_PATH,
+		MqttClientId:           MQTT_CLIENT_ID,
+		SinkRetries:            SINK_RETRIES,
+		MqttTopicPrefix:        MQTT_TOPIC_PREFIX,
+		HomeAssistantPrefix:    MQTT_DISCOVERY_PREFIX,
+		VaultAuth:              VAULT_AUTH_TOKEN,
+	}
+	if err := loader.Load(ctx, cfg); err != nil {
+		return nil, xerrors.Errorf("failed to load config: %w", err)
+	}
+
+	// The flags are loaded on their own, as confita doesn't let a backend override the keys found by an earlier one.
+	if err := confita.NewLoader(newFlagsBackend()).Load(ctx, cfg); err != nil {
+		return nil, xerrors.Errorf("failed to load flags: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// resolveConfig loads the secrets of the config from Vault, AWS and the *_file keys, sets the timezone and fills in
+// the defaults left. It carries on past the failures, returning their errors joined.
+func resolveConfig(ctx context.Context, cfg *Config) error {
+	var errs []error
+
+	if err := loadVaultSecrets(ctx, cfg); err != nil {
+		errs = append(errs, xerrors.Errorf("failed to load vault secrets: %w", err))
+	}
+
+	if err := loadAwsSecrets(ctx, cfg); err != nil {
+		errs = append(errs, xerrors.Errorf("failed to load aws secrets: %w", err))
+	}
+
+	secrets := []struct {
+		path  string
+		value *string
+	}{
+		{cfg.PasswordFile, &cfg.Password},
+		{cfg.ReporterPasswordFile, &cfg.ReporterPassword},
+		{cfg.SessionPassphraseFile, &cfg.SessionPassphrase},
+		{cfg.TelegramBotTokenFile, &cfg.TelegramBotToken},
+		{cfg.SmtpPasswordFile, &cfg.SmtpPassword},
+		{cfg.WebhookSecretFile, &cfg.WebhookSecret},
+		{cfg.NtfyTokenFile, &cfg.NtfyToken},
+		{cfg.MatrixAccessTokenFile, &cfg.MatrixAccessToken},
+		{cfg.MastodonAccessTokenFile, &cfg.MastodonAccessToken},
+		{cfg.MisskeyTokenFile, &cfg.MisskeyToken},
+		{cfg.NostrPrivateKeyFile, &cfg.NostrPrivateKey},
+		{cfg.XConsumerSecretFile, &cfg.XConsumerSecret},
+		{cfg.XAccessTokenSecretFile, &cfg.XAccessTokenSecret},
+		{cfg.MqttPasswordFile, &cfg.MqttPassword},
+	}
+
+	for _, secret := range secrets {
+		if secret.path == "" {
+			continue
+		}
+
+		b, err := os.ReadFile(secret.path)
+		if err != nil {
+			errs = append(errs, xerrors.Errorf("failed to read secret file: %w", err))
+			continue
+		}
+
+		*secret.value = strings.TrimSpace(string(b))
+	}
+
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			errs = append(errs, xerrors.Errorf("failed to load timezone: %w", err))
+		} else {
+			time.Local = loc
+		}
+	}
+
+	if cfg.Milestones == nil {
+		cfg.Milestones = MILESTONES
+	}
+
+	sort.Slice(cfg.Milestones, func(i, j int) bool { return cfg.Milestones[i] < cfg.Milestones[j] })
+
+	if cfg.NostrPrivateKey == "" {
+		cfg.NostrPrivateKey = os.Getenv(NOSTR_PRIVATE_KEY_ENV)
+	}
+
+	if cfg.Langs == nil {
+		cfg.Langs = LANGS
+	}
+
+	return errors.Join(errs...)
+}
+
+// newEnvBackend returns the backend looking up the keys of the config in the environment with CONFIG_ENV_PREFIX.
+func newEnvBackend() backend.Backend {
+	env := confitaEnv.NewBackend()
+
+	return backend.Func("env", func(ctx context.Context, key string) ([]byte, error) {
+		return env.Get(ctx, CONFIG_ENV_PREFIX+key)
+	})
+}
+
+// configFlags are the keys of the config set on the command line.
+var configFlags = map[string]string{}
+
+// addConfigFlags adds a flag for every key of the config to the flags of the command, such as -post-time for post_time.
+func addConfigFlags(fs *flag.FlagSet) {
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		key := strings.Split(field.Tag.Get("config"), ",")[0]
+		name := strings.ReplaceAll(key, "_", "-")
+		if key == "" || fs.Lookup(name) != nil {
+			continue
+		}
+
+		set := func(value string) error {
+			configFlags[key] = value
+			return nil
+		}
+
+		if field.Type.Kind() == reflect.Bool {
+			fs.BoolFunc(name, "set "+key+" of the config", set)
+		} else {
+			fs.Func(name, "set "+key+" of the config", set)
+		}
+	}
+}
+
+// newFlagsBackend returns the backend looking up the keys of the config in configFlags.
+func newFlagsBackend() backend.Backend {
+	return backend.Func("flags", func(ctx context.Context, key string) ([]byte, error) {
+		value, ok := configFlags[key]
+		if !ok {
+			return nil, backend.ErrNotFound
+		}
+
+		return []byte(value), nil
+	})
+}
+
+// findConfig returns the default config in the first format that exists, or path itself.
+func findConfig(path string) string {
+	if path != CONFIG_PATH || existsFile(path) {
+		return path
+	}
+
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, ext := range CONFIG_EXTS {
+		if existsFile(base + ext) {
+			return base + ext
+		}
+	}
+
+	return path
+}
+
+// configFileBackend loads the config file in the format of its extension, if it exists.
+// YAML and TOML are converted to JSON first, so that the keys are the same in every format.
+type configFileBackend struct {
+	path string
+}
+
+func (b *configFileBackend) Unmarshal(ctx context.Context, to interface{}) error {
+	data, err := os.ReadFile(b.path)
+	if os.IsNotExist(err) {
+		return backend.ErrNotFound
+	}
+	if err != nil {
+		return xerrors.Errorf("failed to read config file: %w", err)
+	}
+
+	var v map[string]interface{}
+	switch ext := filepath.Ext(b.path); ext {
+	case ".json":
+		return json.Unmarshal(data, to)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &v)
+	case ".toml":
+		err = toml.Unmarshal(data, &v)
+	default:
+		return xerrors.Errorf("unsupported config format: %s", ext)
+	}
+	if err != nil {
+		return xerrors.Errorf("failed to parse config file: %w", err)
+	}
+
+	data, err = json.Marshal(v)
+	if err != nil {
+		return xerrors.Errorf("failed to convert config file: %w", err)
+	}
+
+	return json.Unmarshal(data, to)
+}
+
+func (b *configFileBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, xerrors.New("not implemented")
+}
+
+func (b *configFileBackend) Name() string {
+	return "file"
+}
+
+// overrideConfig sets the keys of the config found in values, which are converted like the other backends.
+func overrideConfig(ctx context.Context, cfg *Config, name string, values map[string]any) error {
+	loader := confita.NewLoader(backend.Func(name, func(ctx context.Context, key string) ([]byte, error) {
+		value, ok := values[key]
+		if !ok {
+			return nil, backend.ErrNotFound
+		}
+
+		return []byte(fmt.Sprint(value)), nil
+	}))
+
+	return loader.Load(ctx, cfg)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"text/template"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+	"golang.org/x/xerrors"
+)
+
+const HEADLINE_POST_FORMAT = `【{{ .Yesterday }}の統計】
+フォロワー数: {{ .FollowersCount }}({{ formatDiff .FollowersCountDiff }})`
+
+// postDaily posts the text of the daily stats.
+// If headlineTmpl is not nil, a headline rendered with it is posted first and the stats follow as its reply.
+// If chartAltTmpl is not nil, a chart of the recent history is attached on a best-effort basis.
+// If param has a top post, it is quoted.
+// The posts continue thread, skipping the ones it already has, and on failure those created so far are returned.
+func postDaily(ctx context.Context, poster *Poster, store *Store, text string, headlineTmpl, chartAltTmpl *template.Template, now time.Time, param *Param, thread *Thread) (*Thread, error) {
+	texts := []string{text}
+
+	if headlineTmpl != nil {
+		headline, err := bskystats.RenderTemplate(headlineTmpl, param)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to render headline: %w", err)
+		}
+
+		texts = []string{headline, text}
+	}
+
+	var embed *bsky.FeedPost_Embed
+	// The embed is only attached to the first post, so it is not created again when resuming after it.
+	if chartAltTmpl != nil && thread.Posted == 0 {
+		var err error
+		embed, err = newChartEmbed(ctx, poster.client, store, chartAltTmpl, now)
+		if err != nil {
+			slog.Error("failed to create chart", "error", err)
+		}
+	}
+
+	if param.TopPost != nil {
+		embed = newQuoteEmbed(param.TopPost, embed)
+	}
+
+	thread, err := poster.resumeThread(ctx, texts, embed, thread)
+	if err != nil {
+		return thread, xerrors.Errorf("failed to post thread: %w", err)
+	}
+
+	return thread, nil
+}
+
+// isNotable reports whether any metric changed by at least minChange or minRate percent.
+// Thresholds that are not positive are ignored, and without any threshold every change is notable.
+func isNotable(before, after Data, minChange int64, minRate float64) bool {
+	if minChange <= 0 && minRate <= 0 {
+		return true
+	}
+
+	pairs := [][2]int64{
+		{before.Posts, after.Posts},
+		{before.Follows, after.Follows},
+		{before.Followers, after.Followers},
+	}
+
+	for _, pair := range pairs {
+		if minChange > 0 && abs(pair[1]-pair[0]) >= minChange {
+			return true
+		}
+
+		if minRate > 0 && math.Abs(growthRate(pair[0], pair[1])) >= minRate {
+			return true
+		}
+	}
+
+	return false
+}
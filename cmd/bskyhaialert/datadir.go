@@ -0,0 +1,141 @@
+package main
+
+import (
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+	"golang.org/x/xerrors"
+)
+
+// dataDir is where the sessions and the stats are kept.
+var dataDir string
+
+// STATE_FILES are the patterns of the files kept in dataDir, which used to be written to the working directory.
+var STATE_FILES = []string{STATS_DB, STATS_DB + "-*", "auth_*.json", "oauth_*.json", REPORT_DIR}
+
+// defaultDataDir returns $XDG_STATE_HOME/bskyhaialert, falling back to ~/.local/state/bskyhaialert.
+func defaultDataDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "bskyhaialert")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+
+	return filepath.Join(home, ".local", "state", "bskyhaialert")
+}
+
+// statePath returns the path of the state file in dataDir.
+func statePath(name string) string {
+	return filepath.Join(dataDir, name)
+}
+
+// prepareDataDir creates dataDir readable only by the user, moving the state files of the working directory into it.
+func prepareDataDir() error {
+	if err := os.MkdirAll(dataDir, 0o700); err != nil {
+		return xerrors.Errorf("failed to create data dir: %w", err)
+	}
+
+	if err := os.Chmod(dataDir, 0o700); err != nil {
+		return xerrors.Errorf("failed to chmod data dir: %w", err)
+	}
+
+	bskystats.Sessions = bskystats.FileSessionStore{Dir: dataDir}
+
+	for _, pattern := range STATE_FILES {
+		names, err := filepath.Glob(pattern)
+		if err != nil {
+			return xerrors.Errorf("failed to glob state files: %w", err)
+		}
+
+		for _, name := range names {
+			if err := migrateStateFile(name); err != nil {
+				slog.Error("failed to migrate", "name", name, "error", err)
+			}
+		}
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	// The store is created here, as sqlite would create it readable by everyone.
+	file, err := os.OpenFile(statePath(STATS_DB), os.O_CREATE|os.O_RDONLY, 0o600)
+	if err != nil {
+		return xerrors.Errorf("failed to create store: %w", err)
+	}
+
+	file.Close()
+
+	for _, pattern := range STATE_FILES {
+		names, err := filepath.Glob(statePath(pattern))
+		if err != nil {
+			return xerrors.Errorf("failed to glob state files: %w", err)
+		}
+
+		for _, name := range names {
+			if err := chmodState(name); err != nil {
+				return xerrors.Errorf("failed to chmod %s: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// chmodState makes the state file readable only by the user, along with the files in it if it is a directory.
+func chmodState(name string) error {
+	return filepath.WalkDir(name, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return os.Chmod(path, 0o700)
+		}
+
+		return os.Chmod(path, 0o600)
+	})
+}
+
+// migrateStateFile moves the state file of the working directory into dataDir unless it's already there.
+func migrateStateFile(name string) error {
+	dst := statePath(filepath.Base(name))
+
+	src, err := filepath.Abs(name)
+	if err != nil {
+		return xerrors.Errorf("failed to resolve path: %w", err)
+	}
+
+	abs, err := filepath.Abs(dst)
+	if err != nil {
+		return xerrors.Errorf("failed to resolve path: %w", err)
+	}
+
+	if src == abs {
+		return nil
+	}
+
+	if _, err := os.Stat(dst); err == nil {
+		slog.Warn("left as the destination already exists", "name", name, "dst", dst)
+		return nil
+	}
+
+	if dryRun {
+		slog.Info("dry run: move", "name", name, "dst", dst)
+		return nil
+	}
+
+	if err := os.Rename(name, dst); err != nil {
+		return xerrors.Errorf("failed to move: %w", err)
+	}
+
+	slog.Info("moved", "name", name, "dst", dst)
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+	"golang.org/x/xerrors"
+)
+
+// DISCORD_COLOR is the colour of the embed, the Bluesky blue.
+const DISCORD_COLOR = 0x0085ff
+
+type discordMessage struct {
+	Embeds []*discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title  string          `json:"title"`
+	Color  int             `json:"color"`
+	Fields []*discordField `json:"fields"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// newDiscordEmbed builds an embed with a field per metric of the param.
+func newDiscordEmbed(param *Param) *discordEmbed {
+	field := func(name string, count, diff int64) *discordField {
+		return &discordField{Name: name, Value: fmt.Sprintf("%d (%s)", count, bskystats.FormatDiff(diff)), Inline: true}
+	}
+
+	fields := []*discordField{
+		field("ポスト数", param.PostsCount, param.PostsCountDiff),
+		field("フォロー数", param.FollowsCount, param.FollowsCountDiff),
+		field("フォロワー数", param.FollowersCount, param.FollowersCountDiff),
+	}
+
+	if param.Engagement {
+		fields = append(fields,
+			field("いいね", param.LikesReceived, param.LikesReceivedDiff),
+			field("リポスト", param.RepostsReceived, param.RepostsReceivedDiff),
+			field("リプライ", param.RepliesReceived, param.RepliesReceivedDiff),
+			&discordField{Name: "エンゲージメント率", Value: fmt.Sprintf("%.2f%%", param.EngagementRate), Inline: true},
+		)
+	}
+
+	if param.FollowerSnapshot {
+		fields = append(fields,
+			&discordField{Name: "新規フォロワー", Value: fmt.Sprintf("%d", param.FollowersGained), Inline: true},
+			&discordField{Name: "フォロー解除", Value: fmt.Sprintf("%d", param.FollowersLost), Inline: true},
+		)
+	}
+
+	return &discordEmbed{
+		Title:  fmt.Sprintf("%sの統計", param.Yesterday),
+		Color:  DISCORD_COLOR,
+		Fields: fields,
+	}
+}
+
+// sendDiscord posts the stats of the param to the Discord webhook.
+// The URL of the webhook carries its token, so it is left out of the error.
+func sendDiscord(ctx context.Context, webhook string, param *Param) error {
+	if err := postJSON(ctx, webhook, &discordMessage{Embeds: []*discordEmbed{newDiscordEmbed(param)}}); err != nil {
+		return xerrors.Errorf("failed to post to webhook: %w", redactURL(err))
+	}
+
+	return nil
+}
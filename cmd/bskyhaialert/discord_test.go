@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSendDiscordRedactsWebhook(t *testing.T) {
+	withFailingHTTPClient(t)
+
+	const token = "SECRET-webhook-token"
+
+	err := sendDiscord(context.Background(), "https://discord.com/api/webhooks/123/"+token, &Param{Yesterday: "2026-01-01"})
+	if err == nil {
+		t.Fatal("sendDiscord() error = nil, want an error")
+	}
+
+	if strings.Contains(err.Error(), token) {
+		t.Errorf("sendDiscord() error = %q, which contains the token", err)
+	}
+}
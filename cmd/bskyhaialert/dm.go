@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/chat"
+	"github.com/bluesky-social/indigo/xrpc"
+	"golang.org/x/xerrors"
+)
+
+const (
+	DELIVERY_POST = "post"
+	DELIVERY_DM   = "dm"
+
+	// DELIVERY_NONE disables the delivery on Bluesky, leaving the report to the other sinks.
+	DELIVERY_NONE = "none"
+
+	// CHAT_PROXY is the service the chat.bsky.* requests are proxied to by the PDS.
+	CHAT_PROXY = "did:web:api.bsky.chat#bsky_chat"
+
+	// MAX_MESSAGE_LENGTH is the maximum number of graphemes in a direct message.
+	MAX_MESSAGE_LENGTH = 1000
+)
+
+// Messenger sends direct messages to a single recipient on behalf of its client.
+type Messenger struct {
+	client *xrpc.Client
+
+	// chat is a copy of client whose requests are proxied to the chat service.
+	chat *xrpc.Client
+
+	recipient string
+}
+
+func newMessenger(client *xrpc.Client, recipient string) *Messenger {
+	chatClient := *client
+	chatClient.Headers = map[string]string{"atproto-proxy": CHAT_PROXY}
+	for k, v := range client.Headers {
+		chatClient.Headers[k] = v
+	}
+
+	return &Messenger{
+		client:    client,
+		chat:      &chatClient,
+		recipient: strings.TrimPrefix(recipient, "@"),
+	}
+}
+
+// send sends the text to the recipient, split into several messages if it is too long.
+func (m *Messenger) send(ctx context.Context, text string) error {
+	did := m.recipient
+	if !strings.HasPrefix(did, "did:") {
+		out, err := atproto.IdentityResolveHandle(ctx, m.client, did)
+		if err != nil {
+			return xerrors.Errorf("failed to resolve recipient: %w", err)
+		}
+
+		did = out.Did
+	}
+
+	convo, err := chat.ConvoGetConvoForMembers(ctx, m.chat, []string{did})
+	if err != nil {
+		return xerrors.Errorf("failed to get conversation: %w", err)
+	}
+
+	for _, chunk := range splitText(text, MAX_MESSAGE_LENGTH) {
+		if _, err := chat.ConvoSendMessage(ctx, m.chat, &chat.ConvoSendMessage_Input{
+			ConvoId: convo.Convo.Id,
+			Message: &chat.ConvoDefs_MessageInput{Text: chunk},
+		}); err != nil {
+			return xerrors.Errorf("failed to send message: %w", err)
+		}
+	}
+
+	return nil
+}
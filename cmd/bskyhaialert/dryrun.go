@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+	"golang.org/x/xerrors"
+)
+
+// DRY_RUN_CID is a well-formed CID handed out for the records and blobs that are not created in dry-run mode.
+const DRY_RUN_CID = "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"
+
+// dryRun logs the write operations instead of executing them.
+var dryRun bool
+
+// dryRunAllowed are the XRPC procedures that are executed even in dry-run mode, as nothing works without a session.
+var dryRunAllowed = map[string]bool{
+	"com.atproto.server.createSession":  true,
+	"com.atproto.server.refreshSession": true,
+}
+
+// httpClient sends the requests of the sinks, logging the writes in dry-run mode.
+var httpClient = http.DefaultClient
+
+// dryRunTransport passes reads through to the base transport and answers writes with a made-up response after logging them.
+type dryRunTransport struct {
+	base http.RoundTripper
+}
+
+func (t dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	nsid := path.Base(req.URL.Path)
+	if req.Method == http.MethodGet || req.Method == http.MethodHead || dryRunAllowed[nsid] {
+		if t.base == nil {
+			return http.DefaultTransport.RoundTrip(req)
+		}
+
+		return t.base.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to read body: %w", err)
+		}
+
+		req.Body.Close()
+		body = b
+	}
+
+	// Only the bodies of the XRPC requests are logged, as the other services may take credentials in their URLs and bodies.
+	if strings.HasPrefix(req.URL.Path, "/xrpc/") && strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+		slog.Info("dry run: request", "method", req.Method, "nsid", nsid, "body", string(body))
+	} else {
+		slog.Info("dry run: request", "method", req.Method, "host", req.URL.Host, "path", ".../"+nsid, "bytes", len(body))
+	}
+
+	response := "{}"
+	switch nsid {
+	case "com.atproto.repo.createRecord", "com.atproto.repo.putRecord":
+		response = `{"uri":"at://did:plc:dryrun/dryrun/dryrun","cid":"` + DRY_RUN_CID + `"}`
+	case "com.atproto.repo.uploadBlob":
+		response = `{"blob":{"$type":"blob","ref":{"$link":"` + DRY_RUN_CID + `"},"mimeType":"application/octet-stream","size":0}}`
+	}
+
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader([]byte(response))),
+		Request:    req,
+	}, nil
+}
+
+// enableDryRun turns on the dry-run mode for the sinks and the clients.
+func enableDryRun(clients ...*xrpc.Client) {
+	dryRun = true
+	httpClient = &http.Client{Transport: dryRunTransport{}}
+
+	// The transports of the clients are kept for the reads, as they may authorize the requests.
+	for _, client := range clients {
+		client.Client = &http.Client{Transport: dryRunTransport{base: client.Client.Transport}}
+	}
+}
+
+// openStore opens the store, or a temporary copy of it in dry-run mode so that the writes are discarded.
+func openStore() (*Store, error) {
+	if !dryRun {
+		return bskystats.OpenStore(statePath(STATS_DB))
+	}
+
+	tmp, err := os.CreateTemp("", "bskyhaialert-*.db")
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create temporary store: %w", err)
+	}
+
+	defer tmp.Close()
+
+	if b, err := os.ReadFile(statePath(STATS_DB)); err == nil {
+		if _, err := tmp.Write(b); err != nil {
+			return nil, xerrors.Errorf("failed to copy store: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, xerrors.Errorf("failed to read store: %w", err)
+	}
+
+	slog.Info("dry run: using a copy of the store", "store", statePath(STATS_DB), "copy", tmp.Name())
+
+	return bskystats.OpenStore(tmp.Name())
+}
+
+// writeFile writes the file atomically, or only logs it in dry-run mode.
+func writeFile(name string, data []byte, perm os.FileMode) error {
+	if dryRun {
+		slog.Info("dry run: write", "name", name, "bytes", len(data))
+		return nil
+	}
+
+	return bskystats.WriteFileAtomic(name, data, perm)
+}
+
+// dryRunSessionStore loads the sessions from the store but keeps the saved ones in memory, only logging the writes.
+type dryRunSessionStore struct {
+	store bskystats.SessionStore
+
+	mu    sync.Mutex
+	saved map[string][]byte
+}
+
+func (s *dryRunSessionStore) Load(key string) ([]byte, error) {
+	s.mu.Lock()
+	b, ok := s.saved[key]
+	s.mu.Unlock()
+
+	if ok {
+		if b == nil {
+			return nil, bskystats.ErrSessionNotFound
+		}
+
+		return b, nil
+	}
+
+	return s.store.Load(key)
+}
+
+func (s *dryRunSessionStore) Save(key string, b []byte) error {
+	slog.Info("dry run: save session", "key", key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.saved == nil {
+		s.saved = make(map[string][]byte)
+	}
+
+	s.saved[key] = b
+
+	return nil
+}
+
+func (s *dryRunSessionStore) Delete(key string) error {
+	slog.Info("dry run: delete session", "key", key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.saved == nil {
+		s.saved = make(map[string][]byte)
+	}
+
+	s.saved[key] = nil
+
+	return nil
+}
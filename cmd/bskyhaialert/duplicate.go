@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+	"golang.org/x/xerrors"
+)
+
+// DUPLICATE_MARKER_FORMAT is the default text that tells the daily post apart from the other posts of the day.
+// It leaves out the opening bracket, which is followed by the first day covered when the post covers several days.
+const DUPLICATE_MARKER_FORMAT = `{{ .Yesterday }}の統計】`
+
+// isDailyPosted reports whether the account of the client has posted the daily post since from,
+// which is told by the marker rendered with param in its text.
+func isDailyPosted(ctx context.Context, client *xrpc.Client, markerTmpl *template.Template, from time.Time, param *Param) (bool, error) {
+	marker, err := bskystats.RenderTemplate(markerTmpl, param)
+	if err != nil {
+		return false, xerrors.Errorf("failed to render marker: %w", err)
+	}
+
+	if marker == "" {
+		return false, xerrors.New("marker is empty")
+	}
+
+	items, err := fetchFeedBetween(ctx, client, from, time.Now())
+	if err != nil {
+		return false, xerrors.Errorf("failed to fetch feed: %w", err)
+	}
+
+	for _, post := range postsOf(items) {
+		record, ok := post.Record.Val.(*bsky.FeedPost)
+		if ok && strings.Contains(record.Text, marker) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
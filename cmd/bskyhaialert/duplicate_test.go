@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+)
+
+func TestDuplicateMarkerMatchesPost(t *testing.T) {
+	tmpls, err := parseTemplates(&Config{DuplicateMarker: DUPLICATE_MARKER_FORMAT})
+	if err != nil {
+		t.Fatalf("parseTemplates() error = %v", err)
+	}
+
+	day := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		since string
+	}{
+		{"one day", "2026-01-10"},
+		{"caught up", "2026-01-08"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := newParam(day, Data{}, Data{Followers: 1}, RATIO_PRECISION)
+			coverDays(param, tt.since, day.AddDate(0, 0, 1))
+
+			post, err := bskystats.RenderTemplate(tmpls.post.Load(), param)
+			if err != nil {
+				t.Fatalf("failed to render post: %v", err)
+			}
+
+			marker, err := bskystats.RenderTemplate(tmpls.duplicate, param)
+			if err != nil {
+				t.Fatalf("failed to render marker: %v", err)
+			}
+
+			if !strings.Contains(post, marker) {
+				t.Errorf("post %q does not contain marker %q", post, marker)
+			}
+		})
+	}
+}
+
+func TestIsDailyPostedEmptyMarker(t *testing.T) {
+	tmpl := template.Must(template.New("duplicate_marker").Parse(`{{ if false }}x{{ end }}`))
+
+	// The empty marker is rejected before the feed is fetched, so no client is needed.
+	if _, err := isDailyPosted(context.Background(), nil, tmpl, time.Now(), &Param{}); err == nil {
+		t.Error("isDailyPosted() with an empty marker error = nil, want an error")
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/bluesky-social/indigo/xrpc"
+	"golang.org/x/xerrors"
+)
+
+// fetchNotificationCounts counts the notifications received on the day before today by their reasons and records them.
+// It also returns the counts recorded for the day before.
+func fetchNotificationCounts(ctx context.Context, client *xrpc.Client, store *Store, today time.Time) (map[string]int64, map[string]int64, error) {
+	yesterday := today.AddDate(0, 0, -1)
+
+	notifications, err := fetchNotificationsBetween(ctx, client, yesterday, today, nil)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("failed to fetch notifications: %w", err)
+	}
+
+	counts := countReasons(notifications)
+
+	if err := store.SaveNotificationCounts(yesterday, counts); err != nil {
+		return nil, nil, xerrors.Errorf("failed to save notification counts: %w", err)
+	}
+
+	prev, err := store.GetNotificationCounts(yesterday.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, nil, xerrors.Errorf("failed to get notification counts: %w", err)
+	}
+
+	return counts, prev, nil
+}
+
+// updateEngagement sets the interactions received to param along with the differences from the day before.
+func updateEngagement(counts, prev map[string]int64, param *Param) {
+	param.Engagement = true
+	param.LikesReceived = counts["like"]
+	param.LikesReceivedDiff = counts["like"] - prev["like"]
+	param.RepostsReceived = counts["repost"]
+	param.RepostsReceivedDiff = counts["repost"] - prev["repost"]
+	param.RepliesReceived = counts["reply"]
+	param.RepliesReceivedDiff = counts["reply"] - prev["reply"]
+
+	interactions := param.LikesReceived + param.RepostsReceived + param.RepliesReceived
+	param.EngagementRate = engagementRate(interactions, param.FollowersCount+param.FollowersCountDiff)
+	param.InteractionsPerPost = interactionsPerPost(interactions, param.PostsCountDiff)
+}
+
+// engagementRate returns the interactions as a percentage of the followers.
+func engagementRate(interactions, followers int64) float64 {
+	if followers <= 0 {
+		return 0
+	}
+
+	return float64(interactions) / float64(followers) * 100
+}
+
+// interactionsPerPost returns the average interactions for each post published.
+func interactionsPerPost(interactions, posts int64) float64 {
+	if posts <= 0 {
+		return 0
+	}
+
+	return float64(interactions) / float64(posts)
+}
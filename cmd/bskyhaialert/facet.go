@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/xrpc"
+)
+
+const MAX_TAG_LENGTH = 64
+
+var (
+	tagRegexp     = regexp.MustCompile(`(?:^|\s)([#＃]\S+)`)
+	linkRegexp    = regexp.MustCompile(`(?:^|\s|\()(https?://[A-Za-z0-9\-._~:/?#\[\]@!$&'()*+,;=%]+)`)
+	mentionRegexp = regexp.MustCompile(`(?:^|\s|\()(@(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)`)
+)
+
+// buildFacets returns all facets detected in text, ordered by their position.
+func buildFacets(ctx context.Context, client *xrpc.Client, text string) []*bsky.RichtextFacet {
+	facets := parseTagFacets(text)
+	facets = append(facets, parseMentionFacets(ctx, client, text)...)
+	facets = append(facets, parseLinkFacets(text)...)
+
+	sort.Slice(facets, func(i, j int) bool { return facets[i].Index.ByteStart < facets[j].Index.ByteStart })
+
+	return facets
+}
+
+// parseTagFacets detects hashtags in text and returns them as tag facets.
+// Offsets are counted in UTF-8 bytes as required by app.bsky.richtext.facet.
+func parseTagFacets(text string) []*bsky.RichtextFacet {
+	var facets []*bsky.RichtextFacet
+
+	for _, match := range tagRegexp.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[2], match[3]
+
+		_, size := utf8.DecodeRuneInString(text[start:end])
+		tag := strings.TrimRightFunc(text[start+size:end], unicode.IsPunct)
+
+		if tag == "" || utf8.RuneCountInString(tag) > MAX_TAG_LENGTH || isDigits(tag) {
+			continue
+		}
+
+		facets = append(facets, &bsky.RichtextFacet{
+			Index: &bsky.RichtextFacet_ByteSlice{
+				ByteStart: int64(start),
+				ByteEnd:   int64(start + size + len(tag)),
+			},
+			Features: []*bsky.RichtextFacet_Features_Elem{
+				{
+					RichtextFacet_Tag: &bsky.RichtextFacet_Tag{
+						Tag: tag,
+					},
+				},
+			},
+		})
+	}
+
+	return facets
+}
+
+// parseMentionFacets detects handles in text, resolves them to DIDs and returns them as mention facets.
+// Handles that cannot be resolved are left as plain text.
+func parseMentionFacets(ctx context.Context, client *xrpc.Client, text string) []*bsky.RichtextFacet {
+	var facets []*bsky.RichtextFacet
+
+	for _, match := range mentionRegexp.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[2], match[3]
+
+		out, err := atproto.IdentityResolveHandle(ctx, client, text[start+1:end])
+		if err != nil {
+			continue
+		}
+
+		facets = append(facets, &bsky.RichtextFacet{
+			Index: &bsky.RichtextFacet_ByteSlice{
+				ByteStart: int64(start),
+				ByteEnd:   int64(end),
+			},
+			Features: []*bsky.RichtextFacet_Features_Elem{
+				{
+					RichtextFacet_Mention: &bsky.RichtextFacet_Mention{
+						Did: out.Did,
+					},
+				},
+			},
+		})
+	}
+
+	return facets
+}
+
+// parseLinkFacets detects URLs in text and returns them as link facets.
+// Trailing punctuation and unbalanced closing parentheses are not considered part of the URL.
+func parseLinkFacets(text string) []*bsky.RichtextFacet {
+	var facets []*bsky.RichtextFacet
+
+	for _, match := range linkRegexp.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[2], match[3]
+
+		uri := strings.TrimRight(text[start:end], ".,;:!?\"'")
+		if strings.HasSuffix(uri, ")") && !strings.Contains(uri, "(") {
+			uri = strings.TrimSuffix(uri, ")")
+		}
+
+		facets = append(facets, &bsky.RichtextFacet{
+			Index: &bsky.RichtextFacet_ByteSlice{
+				ByteStart: int64(start),
+				ByteEnd:   int64(start + len(uri)),
+			},
+			Features: []*bsky.RichtextFacet_Features_Elem{
+				{
+					RichtextFacet_Link: &bsky.RichtextFacet_Link{
+						Uri: uri,
+					},
+				},
+			},
+		})
+	}
+
+	return facets
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+)
+
+// facetSpan is the byte range and the value of a facet.
+type facetSpan struct {
+	start, end int64
+	value      string
+}
+
+func spansOf(facets []*bsky.RichtextFacet) []facetSpan {
+	var spans []facetSpan
+	for _, facet := range facets {
+		span := facetSpan{start: facet.Index.ByteStart, end: facet.Index.ByteEnd}
+
+		feature := facet.Features[0]
+		switch {
+		case feature.RichtextFacet_Tag != nil:
+			span.value = feature.RichtextFacet_Tag.Tag
+		case feature.RichtextFacet_Link != nil:
+			span.value = feature.RichtextFacet_Link.Uri
+		}
+
+		spans = append(spans, span)
+	}
+
+	return spans
+}
+
+func TestParseTagFacets(t *testing.T) {
+	tests := []struct {
+		text string
+		want []facetSpan
+	}{
+		{"hello #bsky world", []facetSpan{{6, 11, "bsky"}}},
+		{"#tag!", []facetSpan{{0, 4, "tag"}}},
+		{"＃タグ", []facetSpan{{0, 9, "タグ"}}},
+		{"#a #b", []facetSpan{{0, 2, "a"}, {3, 5, "b"}}},
+		{"#123", nil},
+		{"a#b", nil},
+		{"#", nil},
+	}
+
+	for _, tt := range tests {
+		if got := spansOf(parseTagFacets(tt.text)); !slices.Equal(got, tt.want) {
+			t.Errorf("parseTagFacets(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseLinkFacets(t *testing.T) {
+	tests := []struct {
+		text string
+		want []facetSpan
+	}{
+		{"see https://example.com", []facetSpan{{4, 23, "https://example.com"}}},
+		{"see https://example.com.", []facetSpan{{4, 23, "https://example.com"}}},
+		{"(https://example.com)", []facetSpan{{1, 20, "https://example.com"}}},
+		{"https://en.wikipedia.org/wiki/Go_(language)", []facetSpan{{0, 43, "https://en.wikipedia.org/wiki/Go_(language)"}}},
+		{"http://a.example/?q=1&r=2", []facetSpan{{0, 25, "http://a.example/?q=1&r=2"}}},
+		{"nohttps://example.com", nil},
+		{"no links", nil},
+	}
+
+	for _, tt := range tests {
+		if got := spansOf(parseLinkFacets(tt.text)); !slices.Equal(got, tt.want) {
+			t.Errorf("parseLinkFacets(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestIsDigits(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"123", true},
+		{"１２３", true},
+		{"12a", false},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		if got := isDigits(tt.s); got != tt.want {
+			t.Errorf("isDigits(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+	"golang.org/x/xerrors"
+)
+
+const (
+	FEED_PAGE_SIZE   = 100
+	TOP_POSTS_COUNT  = 3
+	TOP_POSTS_FORMAT = `【{{ .Yesterday }}の人気ポスト】
+{{ range .Posts }}
+・いいね{{ .Likes }} リポスト{{ .Reposts }}
+{{ .Url }}
+{{ end }}`
+)
+
+type TopPost struct {
+	Uri     string
+	Cid     string
+	Url     string
+	Text    string
+	Likes   int64
+	Reposts int64
+}
+
+type TopPostsParam struct {
+	Yesterday string
+	Posts     []*TopPost
+}
+
+// fetchFeedBetween returns the posts and reposts of the account made from one time to another, newest first.
+func fetchFeedBetween(ctx context.Context, client *xrpc.Client, from, to time.Time) ([]*bsky.FeedDefs_FeedViewPost, error) {
+	var items []*bsky.FeedDefs_FeedViewPost
+
+	cursor := ""
+	for {
+		out, err := bsky.FeedGetAuthorFeed(ctx, client, client.Auth.Did, cursor, "posts_with_replies", false, FEED_PAGE_SIZE)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get author feed: %w", err)
+		}
+
+		for _, item := range out.Feed {
+			madeAt, err := feedItemTime(item)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to get post date: %w", err)
+			}
+
+			if madeAt.Before(from) {
+				return items, nil
+			}
+
+			if madeAt.Before(to) {
+				items = append(items, item)
+			}
+		}
+
+		if out.Cursor == nil || len(out.Feed) == 0 {
+			return items, nil
+		}
+
+		cursor = *out.Cursor
+	}
+}
+
+// feedItemTime returns when the item was reposted, or when the post was created if it is not a repost.
+func feedItemTime(item *bsky.FeedDefs_FeedViewPost) (time.Time, error) {
+	if isRepost(item) {
+		return time.Parse(time.RFC3339, item.Reason.FeedDefs_ReasonRepost.IndexedAt)
+	}
+
+	return postCreatedAt(item.Post)
+}
+
+func isRepost(item *bsky.FeedDefs_FeedViewPost) bool {
+	return item.Reason != nil && item.Reason.FeedDefs_ReasonRepost != nil
+}
+
+func isReply(post *bsky.FeedDefs_PostView) bool {
+	record, ok := post.Record.Val.(*bsky.FeedPost)
+	return ok && record.Reply != nil
+}
+
+// postsOf returns the posts of the items, leaving out reposts.
+func postsOf(items []*bsky.FeedDefs_FeedViewPost) []*bsky.FeedDefs_PostView {
+	var posts []*bsky.FeedDefs_PostView
+	for _, item := range items {
+		if !isRepost(item) {
+			posts = append(posts, item.Post)
+		}
+	}
+
+	return posts
+}
+
+func postCreatedAt(post *bsky.FeedDefs_PostView) (time.Time, error) {
+	if record, ok := post.Record.Val.(*bsky.FeedPost); ok {
+		return time.Parse(time.RFC3339, record.CreatedAt)
+	}
+
+	return time.Parse(time.RFC3339, post.IndexedAt)
+}
+
+// topPosts returns up to n posts ordered by their likes, then by their reposts.
+func topPosts(posts []*bsky.FeedDefs_PostView, n int) []*TopPost {
+	tops := make([]*TopPost, 0, len(posts))
+	for _, post := range posts {
+		top := &TopPost{
+			Uri: post.Uri,
+			Cid: post.Cid,
+			Url: postURL(post.Uri),
+		}
+
+		if record, ok := post.Record.Val.(*bsky.FeedPost); ok {
+			top.Text = record.Text
+		}
+
+		if post.LikeCount != nil {
+			top.Likes = *post.LikeCount
+		}
+
+		if post.RepostCount != nil {
+			top.Reposts = *post.RepostCount
+		}
+
+		tops = append(tops, top)
+	}
+
+	sort.SliceStable(tops, func(i, j int) bool {
+		if tops[i].Likes != tops[j].Likes {
+			return tops[i].Likes > tops[j].Likes
+		}
+
+		return tops[i].Reposts > tops[j].Reposts
+	})
+
+	if len(tops) > n {
+		tops = tops[:n]
+	}
+
+	return tops
+}
+
+// newQuoteEmbed embeds the post as a quote, along with the images of media if it is not nil.
+func newQuoteEmbed(post *TopPost, media *bsky.FeedPost_Embed) *bsky.FeedPost_Embed {
+	record := &bsky.EmbedRecord{
+		Record: &atproto.RepoStrongRef{
+			Uri: post.Uri,
+			Cid: post.Cid,
+		},
+	}
+
+	if media == nil || media.EmbedImages == nil {
+		return &bsky.FeedPost_Embed{
+			EmbedRecord: record,
+		}
+	}
+
+	return &bsky.FeedPost_Embed{
+		EmbedRecordWithMedia: &bsky.EmbedRecordWithMedia{
+			Media: &bsky.EmbedRecordWithMedia_Media{
+				EmbedImages: media.EmbedImages,
+			},
+			Record: record,
+		},
+	}
+}
+
+// postURL returns the web URL of the post at uri, or uri itself if it cannot be parsed.
+func postURL(uri string) string {
+	aturi, err := syntax.ParseATURI(uri)
+	if err != nil {
+		return uri
+	}
+
+	return fmt.Sprintf("https://bsky.app/profile/%s/post/%s", aturi.Authority(), aturi.RecordKey())
+}
+
+// postTopPosts replies with the top posts of yesterday and returns a reference for replying to the last post.
+func postTopPosts(ctx context.Context, poster *Poster, tmpl *template.Template, reply *bsky.FeedPost_ReplyRef, yesterday string, tops []*TopPost) (*bsky.FeedPost_ReplyRef, error) {
+	text, err := bskystats.RenderTemplate(tmpl, &TopPostsParam{
+		Yesterday: yesterday,
+		Posts:     tops,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to render template: %w", err)
+	}
+
+	thread, err := poster.postThread(ctx, []string{strings.TrimSpace(text)}, nil, reply)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to post: %w", err)
+	}
+
+	return thread.Reply, nil
+}
+
+// lastPostedAt returns when the actor last posted or reposted, or the zero time if the actor never did.
+func lastPostedAt(ctx context.Context, client *xrpc.Client, actor string) (time.Time, error) {
+	out, err := bsky.FeedGetAuthorFeed(ctx, client, actor, "", "posts_with_replies", false, 1)
+	if err != nil {
+		return time.Time{}, xerrors.Errorf("failed to get author feed: %w", err)
+	}
+
+	if len(out.Feed) == 0 {
+		return time.Time{}, nil
+	}
+
+	return feedItemTime(out.Feed[0])
+}
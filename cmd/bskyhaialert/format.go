@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+	"golang.org/x/xerrors"
+)
+
+const (
+	FORMAT_TEXT     = "text"
+	FORMAT_MARKDOWN = "markdown"
+	FORMAT_JSON     = "json"
+)
+
+// Formatter renders the report for a sink.
+type Formatter interface {
+	Format(report *Report) (string, error)
+}
+
+// TextFormatter renders the report with its template as is.
+type TextFormatter struct{}
+
+func (TextFormatter) Format(report *Report) (string, error) {
+	return bskystats.RenderTemplate(report.Template, report.Param)
+}
+
+// MarkdownFormatter renders the report as a heading followed by a list of its lines.
+type MarkdownFormatter struct{}
+
+func (MarkdownFormatter) Format(report *Report) (string, error) {
+	text, err := bskystats.RenderTemplate(report.Template, report.Param)
+	if err != nil {
+		return "", xerrors.Errorf("failed to render template: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %sの統計\n\n", report.Param.Yesterday)
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "【") {
+			continue
+		}
+
+		fmt.Fprintf(&b, "- %s\n", strings.TrimPrefix(line, "・"))
+	}
+
+	return b.String(), nil
+}
+
+// JSONFormatter renders the stats of the report as the JSON of the webhooks.
+type JSONFormatter struct{}
+
+func (JSONFormatter) Format(report *Report) (string, error) {
+	b, err := json.Marshal(&WebhookPayload{Data: report.Data, Param: report.Param})
+	if err != nil {
+		return "", xerrors.Errorf("failed to marshal report: %w", err)
+	}
+
+	return string(b), nil
+}
+
+// parseFormatters returns the formatters keyed by the names of the sinks they are selected for.
+func parseFormatters(formats map[string]string) (map[string]Formatter, error) {
+	formatters := make(map[string]Formatter, len(formats))
+
+	for sink, format := range formats {
+		switch format {
+		case FORMAT_TEXT:
+			formatters[sink] = TextFormatter{}
+		case FORMAT_MARKDOWN:
+			formatters[sink] = MarkdownFormatter{}
+		case FORMAT_JSON:
+			formatters[sink] = JSONFormatter{}
+		default:
+			return nil, xerrors.Errorf("unknown format for %s: %s", sink, format)
+		}
+	}
+
+	return formatters, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"text/template"
+)
+
+func TestParseFormatters(t *testing.T) {
+	formatters, err := parseFormatters(map[string]string{
+		"slack":   FORMAT_MARKDOWN,
+		"discord": FORMAT_TEXT,
+		"ntfy":    FORMAT_JSON,
+	})
+	if err != nil {
+		t.Fatalf("parseFormatters() error = %v", err)
+	}
+
+	if _, ok := formatters["slack"].(MarkdownFormatter); !ok {
+		t.Errorf("slack formatter = %T, want MarkdownFormatter", formatters["slack"])
+	}
+
+	if _, ok := formatters["discord"].(TextFormatter); !ok {
+		t.Errorf("discord formatter = %T, want TextFormatter", formatters["discord"])
+	}
+
+	if _, ok := formatters["ntfy"].(JSONFormatter); !ok {
+		t.Errorf("ntfy formatter = %T, want JSONFormatter", formatters["ntfy"])
+	}
+
+	if _, err := parseFormatters(map[string]string{"slack": "html"}); err == nil {
+		t.Error("parseFormatters() with an unknown format error = nil, want an error")
+	}
+}
+
+func TestFormatters(t *testing.T) {
+	report := &Report{
+		Data:     Data{Posts: 12, Follows: 34, Followers: 56},
+		Param:    &Param{Yesterday: "2026-01-01", PostsCount: 10, PostsCountDiff: 2},
+		Template: template.Must(template.New("post").Parse("【{{ .Yesterday }}の統計】\n・ポスト数: {{ .PostsCount }}\n\nフォロワー数: 56\n")),
+	}
+
+	tests := []struct {
+		name      string
+		formatter Formatter
+		want      string
+	}{
+		{"text", TextFormatter{}, "【2026-01-01の統計】\n・ポスト数: 10\n\nフォロワー数: 56\n"},
+		{"markdown", MarkdownFormatter{}, "## 2026-01-01の統計\n\n- ポスト数: 10\n- フォロワー数: 56\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.formatter.Format(report)
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONFormatter(t *testing.T) {
+	report := &Report{
+		Data:  Data{Posts: 12, Follows: 34, Followers: 56},
+		Param: &Param{Yesterday: "2026-01-01", FollowersCountDiff: -1},
+	}
+
+	text, err := JSONFormatter{}.Format(report)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal([]byte(text), &payload); err != nil {
+		t.Fatalf("failed to unmarshal %q: %v", text, err)
+	}
+
+	if payload.Data != report.Data {
+		t.Errorf("Data = %+v, want %+v", payload.Data, report.Data)
+	}
+
+	if payload.Param == nil || payload.Param.Yesterday != "2026-01-01" || payload.Param.FollowersCountDiff != -1 {
+		t.Errorf("Param = %+v, want the param of the report", payload.Param)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// percent formats diff as a signed percentage of base.
+func percent(base, diff int64) string {
+	return fmt.Sprintf("%+.1f%%", growthRate(base, base+diff))
+}
+
+// comma formats n with thousands separators, e.g. 1234567 as 1,234,567.
+func comma(n int64) string {
+	s := strconv.FormatInt(abs(n), 10)
+
+	var b strings.Builder
+	if n < 0 {
+		b.WriteByte('-')
+	}
+
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
+
+// humanize formats n in a short form, e.g. 1234 as 1.2k and 5600000 as 5.6M.
+func humanize(n int64) string {
+	if abs(n) < 1_000 {
+		return strconv.FormatInt(n, 10)
+	}
+
+	v, unit := float64(n)/1_000, "k"
+	for _, next := range []string{"M", "B"} {
+		// The unit is picked after rounding to a decimal place, so that 999950 is 1M rather than 1000k.
+		if math.Abs(math.Round(v*10)) < 10_000 {
+			break
+		}
+
+		v, unit = v/1_000, next
+	}
+
+	return trimZero(strconv.FormatFloat(v, 'f', 1, 64)) + unit
+}
+
+func trimZero(s string) string {
+	return strings.TrimSuffix(s, ".0")
+}
+
+// sign returns the sign of n as "+", "-" or "±" for zero.
+func sign(n int64) string {
+	if n > 0 {
+		return "+"
+	}
+
+	if n < 0 {
+		return "-"
+	}
+
+	return "±"
+}
+
+// fixed formats v with the given number of decimal places.
+func fixed(v float64, precision int) string {
+	return strconv.FormatFloat(v, 'f', precision, 64)
+}
+
+// formatFloatDiff formats diff like formatDiff with the given number of decimal places.
+func formatFloatDiff(diff float64, precision int) string {
+	s := fixed(math.Abs(diff), precision)
+
+	if strings.Trim(s, "0.") == "" {
+		return "±" + s
+	}
+
+	if diff > 0 {
+		return "+" + s
+	}
+
+	return "-" + s
+}
@@ -0,0 +1,127 @@
+package main
+
+import "testing"
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		base, diff int64
+		want       string
+	}{
+		{200, 50, "+25.0%"},
+		{100, -10, "-10.0%"},
+		{100, 0, "+0.0%"},
+		{0, 5, "+0.0%"},
+	}
+
+	for _, tt := range tests {
+		if got := percent(tt.base, tt.diff); got != tt.want {
+			t.Errorf("percent(%d, %d) = %q, want %q", tt.base, tt.diff, got, tt.want)
+		}
+	}
+}
+
+func TestComma(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1,000"},
+		{1234567, "1,234,567"},
+		{-1234, "-1,234"},
+		{-100, "-100"},
+	}
+
+	for _, tt := range tests {
+		if got := comma(tt.n); got != tt.want {
+			t.Errorf("comma(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestHumanize(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1k"},
+		{1234, "1.2k"},
+		{-1500, "-1.5k"},
+		{5600000, "5.6M"},
+		{2000000000, "2B"},
+		// The unit is picked after rounding.
+		{999_949, "999.9k"},
+		{999_950, "1M"},
+		{999_999, "1M"},
+		{-999_950, "-1M"},
+		{999_949_999, "999.9M"},
+		{999_950_000, "1B"},
+		{999_999_999, "1B"},
+		{1_500_000_000_000, "1500B"},
+	}
+
+	for _, tt := range tests {
+		if got := humanize(tt.n); got != tt.want {
+			t.Errorf("humanize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{1, "+"},
+		{-1, "-"},
+		{0, "±"},
+	}
+
+	for _, tt := range tests {
+		if got := sign(tt.n); got != tt.want {
+			t.Errorf("sign(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestFixed(t *testing.T) {
+	tests := []struct {
+		v         float64
+		precision int
+		want      string
+	}{
+		{1.5, 2, "1.50"},
+		{2, 0, "2"},
+		{-0.125, 3, "-0.125"},
+	}
+
+	for _, tt := range tests {
+		if got := fixed(tt.v, tt.precision); got != tt.want {
+			t.Errorf("fixed(%v, %d) = %q, want %q", tt.v, tt.precision, got, tt.want)
+		}
+	}
+}
+
+func TestFormatFloatDiff(t *testing.T) {
+	tests := []struct {
+		diff      float64
+		precision int
+		want      string
+	}{
+		{0.5, 2, "+0.50"},
+		{-1.26, 1, "-1.3"},
+		{0, 2, "±0.00"},
+		// A diff rounded to zero is not signed.
+		{0.001, 2, "±0.00"},
+		{-0.001, 2, "±0.00"},
+	}
+
+	for _, tt := range tests {
+		if got := formatFloatDiff(tt.diff, tt.precision); got != tt.want {
+			t.Errorf("formatFloatDiff(%v, %d) = %q, want %q", tt.diff, tt.precision, got, tt.want)
+		}
+	}
+}
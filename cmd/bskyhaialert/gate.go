@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/lex/util"
+	"golang.org/x/xerrors"
+)
+
+// threadgateRecord mirrors bsky.FeedThreadgate, but keeps an empty allow list,
+// which means that nobody can reply, instead of omitting it.
+type threadgateRecord struct {
+	LexiconTypeID string                            `json:"$type"`
+	Allow         []*bsky.FeedThreadgate_Allow_Elem `json:"allow"`
+	CreatedAt     string                            `json:"createdAt"`
+	Post          string                            `json:"post"`
+}
+
+type threadgateInput struct {
+	Collection string            `json:"collection"`
+	Repo       string            `json:"repo"`
+	Rkey       string            `json:"rkey"`
+	Record     *threadgateRecord `json:"record"`
+}
+
+// parseThreadgate returns the rules allowing replies for the given setting,
+// which is one of "nobody", "followers", "following" and "mentioned".
+// It returns nil for an empty setting, meaning that anyone can reply.
+func parseThreadgate(name string) ([]*bsky.FeedThreadgate_Allow_Elem, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "nobody":
+		return []*bsky.FeedThreadgate_Allow_Elem{}, nil
+	case "followers":
+		return []*bsky.FeedThreadgate_Allow_Elem{{FeedThreadgate_FollowerRule: new(bsky.FeedThreadgate_FollowerRule)}}, nil
+	case "following":
+		return []*bsky.FeedThreadgate_Allow_Elem{{FeedThreadgate_FollowingRule: new(bsky.FeedThreadgate_FollowingRule)}}, nil
+	case "mentioned":
+		return []*bsky.FeedThreadgate_Allow_Elem{{FeedThreadgate_MentionRule: new(bsky.FeedThreadgate_MentionRule)}}, nil
+	default:
+		return nil, xerrors.Errorf("unknown threadgate: %s", name)
+	}
+}
+
+// createThreadgate restricts replies to the post at uri to the allow rules.
+func (p *Poster) createThreadgate(ctx context.Context, uri string, allow []*bsky.FeedThreadgate_Allow_Elem) error {
+	aturi, err := syntax.ParseATURI(uri)
+	if err != nil {
+		return xerrors.Errorf("failed to parse post uri: %w", err)
+	}
+
+	input := &threadgateInput{
+		Collection: "app.bsky.feed.threadgate",
+		Repo:       p.client.Auth.Did,
+		Rkey:       aturi.RecordKey().String(),
+		Record: &threadgateRecord{
+			LexiconTypeID: "app.bsky.feed.threadgate",
+			Allow:         allow,
+			CreatedAt:     time.Now().UTC().Format(ISO8601),
+			Post:          uri,
+		},
+	}
+
+	var out atproto.RepoCreateRecord_Output
+	if err := p.client.LexDo(ctx, util.Procedure, "application/json", "com.atproto.repo.createRecord", nil, input, &out); err != nil {
+		return xerrors.Errorf("failed to create threadgate: %w", err)
+	}
+
+	return nil
+}
+
+// createPostgate prevents the post at uri from being quoted.
+func (p *Poster) createPostgate(ctx context.Context, uri string) error {
+	aturi, err := syntax.ParseATURI(uri)
+	if err != nil {
+		return xerrors.Errorf("failed to parse post uri: %w", err)
+	}
+
+	rkey := aturi.RecordKey().String()
+
+	if _, err := atproto.RepoCreateRecord(ctx, p.client, &atproto.RepoCreateRecord_Input{
+		Collection: "app.bsky.feed.postgate",
+		Repo:       p.client.Auth.Did,
+		Rkey:       &rkey,
+		Record: &util.LexiconTypeDecoder{
+			Val: &bsky.FeedPostgate{
+				EmbeddingRules: []*bsky.FeedPostgate_EmbeddingRules_Elem{
+					{FeedPostgate_DisableRule: new(bsky.FeedPostgate_DisableRule)},
+				},
+				CreatedAt: time.Now().UTC().Format(ISO8601),
+				Post:      uri,
+			},
+		},
+	}); err != nil {
+		return xerrors.Errorf("failed to create postgate: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/xrpc"
+	"golang.org/x/xerrors"
+)
+
+const GRAPH_PAGE_SIZE = 100
+
+// Churn is the change of the followers since the previous snapshot.
+type Churn struct {
+	Date   string  `json:"date"`
+	Gained []Actor `json:"gained"`
+	Lost   []Actor `json:"lost"`
+}
+
+// fetchFollowers returns all accounts following the account.
+func fetchFollowers(ctx context.Context, client *xrpc.Client) ([]Actor, error) {
+	var actors []Actor
+
+	cursor := ""
+	for {
+		out, err := bsky.GraphGetFollowers(ctx, client, client.Auth.Did, cursor, GRAPH_PAGE_SIZE)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get followers: %w", err)
+		}
+
+		for _, follower := range out.Followers {
+			actors = append(actors, Actor{Did: follower.Did, Handle: follower.Handle})
+		}
+
+		if out.Cursor == nil || len(out.Followers) == 0 {
+			return actors, nil
+		}
+
+		cursor = *out.Cursor
+	}
+}
+
+// fetchFollows returns all accounts the account follows.
+func fetchFollows(ctx context.Context, client *xrpc.Client) ([]Actor, error) {
+	var actors []Actor
+
+	cursor := ""
+	for {
+		out, err := bsky.GraphGetFollows(ctx, client, client.Auth.Did, cursor, GRAPH_PAGE_SIZE)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get follows: %w", err)
+		}
+
+		for _, follow := range out.Follows {
+			actors = append(actors, Actor{Did: follow.Did, Handle: follow.Handle})
+		}
+
+		if out.Cursor == nil || len(out.Follows) == 0 {
+			return actors, nil
+		}
+
+		cursor = *out.Cursor
+	}
+}
+
+// diffActors returns the actors only in after as gained and the ones only in before as lost.
+func diffActors(before, after []Actor) ([]Actor, []Actor) {
+	beforeDids := make(map[string]bool, len(before))
+	for _, actor := range before {
+		beforeDids[actor.Did] = true
+	}
+
+	afterDids := make(map[string]bool, len(after))
+	for _, actor := range after {
+		afterDids[actor.Did] = true
+	}
+
+	var gained, lost []Actor
+	for _, actor := range after {
+		if !beforeDids[actor.Did] {
+			gained = append(gained, actor)
+		}
+	}
+
+	for _, actor := range before {
+		if !afterDids[actor.Did] {
+			lost = append(lost, actor)
+		}
+	}
+
+	return gained, lost
+}
+
+// updateFollowerSnapshot records the followers as of today and sets the accounts gained and lost
+// since the previous snapshot to param. Nothing is set if there is no previous snapshot.
+// It returns the followers as of today and their churn, which is nil without a previous snapshot.
+func updateFollowerSnapshot(ctx context.Context, client *xrpc.Client, store *Store, today time.Time, param *Param) ([]Actor, *Churn, error) {
+	followers, err := fetchFollowers(ctx, client)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("failed to fetch followers: %w", err)
+	}
+
+	prev, err := store.GetLatestGraphBefore(today, GRAPH_FOLLOWERS)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("failed to get previous followers: %w", err)
+	}
+
+	if err := store.SaveGraph(today, GRAPH_FOLLOWERS, followers); err != nil {
+		return nil, nil, xerrors.Errorf("failed to save followers: %w", err)
+	}
+
+	if prev == nil {
+		return followers, nil, nil
+	}
+
+	gained, lost := diffActors(prev, followers)
+
+	param.FollowerSnapshot = true
+	param.FollowersGained = int64(len(gained))
+	param.FollowersLost = int64(len(lost))
+	for _, actor := range lost {
+		param.UnfollowerHandles = append(param.UnfollowerHandles, actor.Handle)
+	}
+
+	churn := &Churn{
+		Date:   today.Format(DATE_FORMAT),
+		Gained: gained,
+		Lost:   lost,
+	}
+
+	return followers, churn, nil
+}
+
+// updateMutuals records the follows as of today and sets the numbers of mutuals
+// and of follows not following back, given the followers as of today, to param.
+func updateMutuals(ctx context.Context, client *xrpc.Client, store *Store, today time.Time, followers []Actor, param *Param) error {
+	follows, err := fetchFollows(ctx, client)
+	if err != nil {
+		return xerrors.Errorf("failed to fetch follows: %w", err)
+	}
+
+	if err := store.SaveGraph(today, GRAPH_FOLLOWS, follows); err != nil {
+		return xerrors.Errorf("failed to save follows: %w", err)
+	}
+
+	nonMutuals, _ := diffActors(followers, follows)
+
+	param.Mutuals = true
+	param.MutualsCount = int64(len(follows) - len(nonMutuals))
+	param.NonMutualFollowsCount = int64(len(nonMutuals))
+
+	return nil
+}
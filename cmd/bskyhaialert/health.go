@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	// HEALTH_BEAT_INTERVAL is how often the scheduler reports that it is alive.
+	HEALTH_BEAT_INTERVAL = time.Minute
+
+	// HEALTH_BEAT_TIMEOUT is how long the scheduler may go without reporting before it is considered wedged.
+	HEALTH_BEAT_TIMEOUT = 5 * HEALTH_BEAT_INTERVAL
+)
+
+// Health is the state of the bot reported by the health endpoints.
+type Health struct {
+	mu sync.Mutex
+
+	sessionValid bool
+	sessionError string
+	lastBeat     time.Time
+	lastFetch    time.Time
+	lastPost     time.Time
+}
+
+// HealthStatus is the body of the responses of the health endpoints.
+type HealthStatus struct {
+	Ok           bool       `json:"ok"`
+	SessionValid bool       `json:"session_valid"`
+	SessionError string     `json:"session_error,omitempty"`
+	SchedulerOk  bool       `json:"scheduler_ok"`
+	LastBeat     *time.Time `json:"last_beat,omitempty"`
+	LastFetch    *time.Time `json:"last_fetch,omitempty"`
+	LastPost     *time.Time `json:"last_post,omitempty"`
+}
+
+// health is updated by the jobs as they run.
+var health = &Health{}
+
+// session records whether the sessions were renewed or resumed, with the error if they were not.
+func (h *Health) session(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.sessionValid = err == nil
+	h.sessionError = ""
+	if err != nil {
+		h.sessionError = err.Error()
+	}
+}
+
+// beat records that the scheduler is alive at now.
+func (h *Health) beat(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastBeat = now
+}
+
+// fetched records that the stats were fetched at now.
+func (h *Health) fetched(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastFetch = now
+}
+
+// posted records that the daily report was delivered at now.
+func (h *Health) posted(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastPost = now
+}
+
+// status returns the state as of now. Ok is whether the scheduler is alive, or also whether the sessions are valid if ready is true.
+func (h *Health) status(now time.Time, ready bool) *HealthStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	timeOf := func(t time.Time) *time.Time {
+		if t.IsZero() {
+			return nil
+		}
+
+		return &t
+	}
+
+	status := &HealthStatus{
+		SessionValid: h.sessionValid,
+		SessionError: h.sessionError,
+		SchedulerOk:  !h.lastBeat.IsZero() && now.Sub(h.lastBeat) < HEALTH_BEAT_TIMEOUT,
+		LastBeat:     timeOf(h.lastBeat),
+		LastFetch:    timeOf(h.lastFetch),
+		LastPost:     timeOf(h.lastPost),
+	}
+
+	status.Ok = status.SchedulerOk && (!ready || status.SessionValid)
+
+	return status
+}
+
+// serveHealth serves /healthz, which fails when the scheduler is wedged, and /readyz, which also fails
+// when the sessions are not valid, on addr in the background.
+func serveHealth(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return xerrors.Errorf("failed to listen: %w", err)
+	}
+
+	handler := func(ready bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			status := health.status(time.Now(), ready)
+
+			w.Header().Set("Content-Type", "application/json")
+			if !status.Ok {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+
+			json.NewEncoder(w).Encode(status)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", handler(false))
+	mux.HandleFunc("GET /readyz", handler(true))
+
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			slog.Error("failed to serve health", "error", err)
+		}
+	}()
+
+	slog.Info("serving health", "addr", listener.Addr().String())
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+const MQTT_DISCOVERY_PREFIX = "homeassistant"
+
+var nodeIdPattern = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+type haSensor struct {
+	metric    string
+	component string
+	name      string
+	icon      string
+}
+
+// haSensors are the metrics announced to Home Assistant, in the order of the report.
+var haSensors = []haSensor{
+	{metric: "posts", component: "sensor", name: "ポスト数", icon: "mdi:message-text"},
+	{metric: "follows", component: "sensor", name: "フォロー数", icon: "mdi:account-arrow-right"},
+	{metric: "followers", component: "sensor", name: "フォロワー数", icon: "mdi:account-group"},
+	{metric: "lists", component: "sensor", name: "リスト数", icon: "mdi:format-list-bulleted"},
+	{metric: "feedgens", component: "sensor", name: "フィード数", icon: "mdi:rss"},
+	{metric: "starter_packs", component: "sensor", name: "スターターパック数", icon: "mdi:package-variant"},
+	{metric: "labeler", component: "binary_sensor", name: "ラベラー", icon: "mdi:label"},
+}
+
+type haDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+}
+
+type haConfig struct {
+	Name       string    `json:"name"`
+	UniqueId   string    `json:"unique_id"`
+	StateTopic string    `json:"state_topic"`
+	Icon       string    `json:"icon"`
+	StateClass string    `json:"state_class,omitempty"`
+	PayloadOn  string    `json:"payload_on,omitempty"`
+	PayloadOff string    `json:"payload_off,omitempty"`
+	Device     *haDevice `json:"device"`
+}
+
+// addDiscoveryConfigs adds the Home Assistant MQTT discovery configs of the metrics to the payloads,
+// so that they show up as the sensors of a device named after the handle.
+func (m *Mqtt) addDiscoveryConfigs(payloads map[string]string) error {
+	nodeId := nodeIdPattern.ReplaceAllString(strings.ToLower(m.handle), "_")
+
+	device := &haDevice{
+		Identifiers:  []string{"bskyhaialert_" + nodeId},
+		Name:         m.handle,
+		Manufacturer: "Bluesky",
+	}
+
+	for _, sensor := range haSensors {
+		config := &haConfig{
+			Name:       sensor.name,
+			UniqueId:   fmt.Sprintf("%s_%s", nodeId, sensor.metric),
+			StateTopic: m.topic(sensor.metric),
+			Icon:       sensor.icon,
+			Device:     device,
+		}
+
+		if sensor.component == "binary_sensor" {
+			config.PayloadOn = "true"
+			config.PayloadOff = "false"
+		} else {
+			config.StateClass = "measurement"
+		}
+
+		b, err := json.Marshal(config)
+		if err != nil {
+			return xerrors.Errorf("failed to marshal config of %s: %w", sensor.metric, err)
+		}
+
+		topic := fmt.Sprintf("%s/%s/%s/%s/config", m.discoveryPrefix, sensor.component, nodeId, sensor.metric)
+		payloads[topic] = string(b)
+	}
+
+	return nil
+}
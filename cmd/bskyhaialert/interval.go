@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"text/template"
+	"time"
+
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+	"golang.org/x/xerrors"
+)
+
+const INTERVAL_POST_FORMAT = `【{{ .From }}〜{{ .To }}の統計】
+ポスト数: {{ .PostsCount }}({{ formatDiff .PostsCountDiff }})
+フォロー数: {{ .FollowsCount }}({{ formatDiff .FollowsCountDiff }})
+フォロワー数: {{ .FollowersCount }}({{ formatDiff .FollowersCountDiff }})`
+
+// INTERVAL_TIME_FORMAT is the format of the times in the interval posts.
+const INTERVAL_TIME_FORMAT = "01/02 15:04"
+
+// IntervalParam is the param of the interval post, covering the time from From to To.
+type IntervalParam struct {
+	*Param
+
+	From string
+	To   string
+}
+
+// runInterval posts the change since the snapshot taken by the previous run and records the current one.
+// Nothing is posted on the first run, which only takes the snapshot to compare with.
+func (a *App) runInterval(ctx context.Context, tmpl *template.Template, now time.Time) error {
+	var newData Data
+	err := a.withSession(ctx, func() (err error) {
+		newData, err = bskystats.FetchData(ctx, a.client)
+		return err
+	})
+	if err != nil {
+		return xerrors.Errorf("failed to fetch data: %w", err)
+	}
+
+	from, data, err := a.store.GetLatestIntervalStats()
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return xerrors.Errorf("failed to load previous stats: %w", err)
+	}
+
+	if err := a.store.SaveIntervalStats(now, newData); err != nil {
+		return xerrors.Errorf("failed to save stats: %w", err)
+	}
+
+	if from.IsZero() {
+		slog.Info("no previous interval stats, skipping interval post")
+		return nil
+	}
+
+	param := newParam(from, data, newData, a.cfg.RatioPrecision)
+
+	return a.poster.postTemplate(ctx, tmpl, &IntervalParam{
+		Param: param,
+		From:  from.In(now.Location()).Format(INTERVAL_TIME_FORMAT),
+		To:    now.Format(INTERVAL_TIME_FORMAT),
+	})
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+)
+
+func TestIntervalPostFormat(t *testing.T) {
+	tmpls, err := parseTemplates(&Config{IntervalTemplate: INTERVAL_POST_FORMAT})
+	if err != nil {
+		t.Fatalf("parseTemplates() error = %v", err)
+	}
+
+	from := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	to := from.Add(6 * time.Hour)
+
+	param := &IntervalParam{
+		Param: newParam(from, Data{Posts: 10, Follows: 20, Followers: 30}, Data{Posts: 12, Follows: 20, Followers: 29}, RATIO_PRECISION),
+		From:  from.Format(INTERVAL_TIME_FORMAT),
+		To:    to.Format(INTERVAL_TIME_FORMAT),
+	}
+
+	got, err := bskystats.RenderTemplate(tmpls.interval, param)
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+
+	want := `【01/10 09:00〜01/10 15:00の統計】
+ポスト数: 10(+2)
+フォロー数: 20(±0)
+フォロワー数: 30(-1)`
+
+	if got != want {
+		t.Errorf("rendered %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"text/template"
+	"time"
+
+	"github.com/bluesky-social/indigo/xrpc"
+	"golang.org/x/xerrors"
+)
+
+const (
+	LEADERBOARD_SIZE   = 5
+	LEADERBOARD_FORMAT = `【今週のありがとうランキング】
+{{ range .Entries }}{{ .Rank }}. @{{ .Handle }} ({{ .Interactions }})
+{{ end }}いつもありがとうございます！`
+)
+
+type LeaderboardEntry struct {
+	Rank         int
+	Did          string
+	Handle       string
+	Interactions int64
+}
+
+type LeaderboardParam struct {
+	Entries []*LeaderboardEntry
+}
+
+// postLeaderboard thanks the accounts that liked, reposted or replied to the account the most in the week before now.
+// Accounts in optOut, given by handle or DID, are left out.
+func postLeaderboard(ctx context.Context, client *xrpc.Client, poster *Poster, tmpl *template.Template, now time.Time, optOut []string) error {
+	notifications, err := fetchNotificationsBetween(ctx, client, now.AddDate(0, 0, -7), now, []string{"like", "repost", "reply"})
+	if err != nil {
+		return xerrors.Errorf("failed to fetch notifications: %w", err)
+	}
+
+	excluded := map[string]bool{client.Auth.Did: true}
+	for _, id := range optOut {
+		excluded[id] = true
+	}
+
+	entries := make(map[string]*LeaderboardEntry)
+	for _, notification := range notifications {
+		author := notification.Author
+		if excluded[author.Did] || excluded[author.Handle] {
+			continue
+		}
+
+		entry, ok := entries[author.Did]
+		if !ok {
+			entry = &LeaderboardEntry{
+				Did:    author.Did,
+				Handle: author.Handle,
+			}
+			entries[author.Did] = entry
+		}
+
+		entry.Interactions++
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	param := &LeaderboardParam{
+		Entries: make([]*LeaderboardEntry, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		param.Entries = append(param.Entries, entry)
+	}
+
+	sort.Slice(param.Entries, func(i, j int) bool {
+		if param.Entries[i].Interactions != param.Entries[j].Interactions {
+			return param.Entries[i].Interactions > param.Entries[j].Interactions
+		}
+
+		return param.Entries[i].Handle < param.Entries[j].Handle
+	})
+
+	if len(param.Entries) > LEADERBOARD_SIZE {
+		param.Entries = param.Entries[:LEADERBOARD_SIZE]
+	}
+
+	for i, entry := range param.Entries {
+		entry.Rank = i + 1
+	}
+
+	return poster.postTemplate(ctx, tmpl, param)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/xrpc"
+	"golang.org/x/xerrors"
+)
+
+const RECORD_PAGE_SIZE = 100
+
+// countLikesGiven counts the likes the account gave from one time to another.
+func countLikesGiven(ctx context.Context, client *xrpc.Client, from, to time.Time) (int64, error) {
+	var count int64
+
+	cursor := ""
+	for {
+		out, err := atproto.RepoListRecords(ctx, client, "app.bsky.feed.like", cursor, RECORD_PAGE_SIZE, client.Auth.Did, false)
+		if err != nil {
+			return 0, xerrors.Errorf("failed to list likes: %w", err)
+		}
+
+		for _, record := range out.Records {
+			like, ok := record.Value.Val.(*bsky.FeedLike)
+			if !ok {
+				continue
+			}
+
+			createdAt, err := time.Parse(time.RFC3339, like.CreatedAt)
+			if err != nil {
+				return 0, xerrors.Errorf("failed to parse like date: %w", err)
+			}
+
+			if createdAt.Before(from) {
+				return count, nil
+			}
+
+			if createdAt.Before(to) {
+				count++
+			}
+		}
+
+		if out.Cursor == nil || len(out.Records) == 0 {
+			return count, nil
+		}
+
+		cursor = *out.Cursor
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	// LOG_MAX_SIZE is the default size in megabytes the log file is rotated at.
+	LOG_MAX_SIZE = 100
+
+	// LOG_BACKUP_TIME_FORMAT is the format of the time the rotated log files are suffixed with.
+	// The milliseconds keep the rotations in the same second apart.
+	LOG_BACKUP_TIME_FORMAT = "20060102T150405.000"
+)
+
+// RotatingFile is a log file that is renamed with the time as a suffix once it grows over maxSize bytes.
+// The rotated files older than maxAge or beyond the newest maxBackups are removed, unless those are zero.
+type RotatingFile struct {
+	mu sync.Mutex
+
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+// openRotatingFile opens the log file at path for appending, creating it and its directory as needed.
+func openRotatingFile(path string, maxSize int64, maxAge time.Duration, maxBackups int) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, maxSize: maxSize, maxAge: maxAge, maxBackups: maxBackups}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return xerrors.Errorf("failed to create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return xerrors.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return xerrors.Errorf("failed to stat log file: %w", err)
+	}
+
+	f.file, f.size = file, info.Size()
+
+	return nil
+}
+
+// Write appends p to the file, rotating it first if p would make it grow over the max size.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(time.Now()); err != nil {
+			return 0, xerrors.Errorf("failed to rotate log file: %w", err)
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.file.Close()
+}
+
+// rotate renames the file with the time of now as a suffix, opens a new one and removes the expired backups.
+// If the file cannot be renamed, it is opened again so that the logs keep being appended to it.
+func (f *RotatingFile) rotate(now time.Time) error {
+	if err := f.file.Close(); err != nil {
+		return xerrors.Errorf("failed to close log file: %w", err)
+	}
+
+	ext := filepath.Ext(f.path)
+	backup := strings.TrimSuffix(f.path, ext) + "-" + now.Format(LOG_BACKUP_TIME_FORMAT) + ext
+	if err := os.Rename(f.path, backup); err != nil {
+		if err := f.open(); err != nil {
+			return xerrors.Errorf("failed to reopen log file: %w", err)
+		}
+
+		return xerrors.Errorf("failed to rename log file: %w", err)
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	return f.removeBackups(now)
+}
+
+// removeBackups removes the rotated files older than maxAge or beyond the newest maxBackups.
+// Only the files suffixed with a time in LOG_BACKUP_TIME_FORMAT are taken as backups.
+func (f *RotatingFile) removeBackups(now time.Time) error {
+	ext := filepath.Ext(f.path)
+	base := strings.TrimSuffix(f.path, ext)
+
+	matches, err := filepath.Glob(base + "-*" + ext)
+	if err != nil {
+		return xerrors.Errorf("failed to list backups: %w", err)
+	}
+
+	var backups []string
+	for _, match := range matches {
+		if isBackupOf(base, ext, match) {
+			backups = append(backups, match)
+		}
+	}
+
+	// The suffixes sort in time order, so the newest come first.
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+
+	for i, backup := range backups {
+		expired := f.maxBackups > 0 && i >= f.maxBackups
+		if f.maxAge > 0 {
+			if info, err := os.Stat(backup); err == nil && now.Sub(info.ModTime()) > f.maxAge {
+				expired = true
+			}
+		}
+
+		if !expired {
+			continue
+		}
+
+		if err := os.Remove(backup); err != nil {
+			return xerrors.Errorf("failed to remove backup: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// isBackupOf reports whether name is a backup of the file at base+ext, suffixed with a time in LOG_BACKUP_TIME_FORMAT.
+func isBackupOf(base, ext, name string) bool {
+	suffix, ok := strings.CutPrefix(name, base+"-")
+	if !ok {
+		return false
+	}
+
+	suffix, ok = strings.CutSuffix(suffix, ext)
+	if !ok {
+		return false
+	}
+
+	_, err := time.Parse(LOG_BACKUP_TIME_FORMAT, suffix)
+	return err == nil
+}
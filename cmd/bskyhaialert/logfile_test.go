@@ -0,0 +1,182 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestIsBackupOf(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"/logs/bot-20260101T000000.000.log", true},
+		{"/logs/bot-20261231T235959.999.log", true},
+		{"/logs/bot-notes.log", false},
+		{"/logs/bot-20260101T000000.log", false},
+		{"/logs/bot-20260101T000000.000.log.gz", false},
+		{"/logs/other-20260101T000000.000.log", false},
+		{"/logs/bot.log", false},
+	}
+
+	for _, tt := range tests {
+		if got := isBackupOf("/logs/bot", ".log", tt.name); got != tt.want {
+			t.Errorf("isBackupOf(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRotatingFileWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.log")
+
+	f, err := openRotatingFile(path, 10, 0, 0)
+	if err != nil {
+		t.Fatalf("openRotatingFile() error = %v", err)
+	}
+
+	defer f.Close()
+
+	for _, s := range []string{"123456", "abcdef"} {
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatalf("Write(%q) error = %v", s, err)
+		}
+	}
+
+	if got := readFile(t, path); got != "abcdef" {
+		t.Errorf("log file = %q, want %q", got, "abcdef")
+	}
+
+	backups := backupsOf(t, path)
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v, want one", backups)
+	}
+
+	if got := readFile(t, backups[0]); got != "123456" {
+		t.Errorf("backup = %q, want %q", got, "123456")
+	}
+}
+
+func TestRotatingFileRemoveBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bot.log")
+
+	// A file that only looks like a backup is left alone.
+	unrelated := filepath.Join(dir, "bot-notes.log")
+	if err := os.WriteFile(unrelated, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := openRotatingFile(path, 10, time.Hour, 2)
+	if err != nil {
+		t.Fatalf("openRotatingFile() error = %v", err)
+	}
+
+	defer f.Close()
+
+	now := time.Now()
+
+	// An expired backup is removed by age, even though it is within maxBackups.
+	expired := filepath.Join(dir, "bot-"+now.Add(-3*time.Hour).Format(LOG_BACKUP_TIME_FORMAT)+".log")
+	if err := os.WriteFile(expired, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(expired, now.Add(-2*time.Hour), now.Add(-2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	times := []time.Time{now, now.Add(time.Millisecond), now.Add(2 * time.Millisecond)}
+	for _, at := range times {
+		if err := f.rotate(at); err != nil {
+			t.Fatalf("rotate(%v) error = %v", at, err)
+		}
+	}
+
+	var want []string
+	for _, at := range times[1:] {
+		want = append(want, filepath.Join(dir, "bot-"+at.Format(LOG_BACKUP_TIME_FORMAT)+".log"))
+	}
+
+	if got := backupsOf(t, path); !slices.Equal(got, want) {
+		t.Errorf("backups = %v, want %v", got, want)
+	}
+
+	if _, err := os.Stat(unrelated); err != nil {
+		t.Errorf("unrelated file was removed: %v", err)
+	}
+}
+
+func TestRotatingFileRenameFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bot.log")
+
+	// The max size is not reached by the writes, which would rotate the file on their own.
+	f, err := openRotatingFile(path, 100, 0, 0)
+	if err != nil {
+		t.Fatalf("openRotatingFile() error = %v", err)
+	}
+
+	defer f.Close()
+
+	if _, err := f.Write([]byte("before")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	// A non-empty directory in the way of the backup makes the rename fail.
+	now := time.Now()
+	blocker := filepath.Join(dir, "bot-"+now.Format(LOG_BACKUP_TIME_FORMAT)+".log")
+	if err := os.MkdirAll(filepath.Join(blocker, "x"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.rotate(now); err == nil {
+		t.Fatal("rotate() error = nil, want an error")
+	}
+
+	// The file is opened again, so the logs keep being written to it.
+	if _, err := f.Write([]byte("+after")); err != nil {
+		t.Fatalf("Write() after the failed rotation error = %v", err)
+	}
+
+	if got := readFile(t, path); got != "before+after" {
+		t.Errorf("log file = %q, want %q", got, "before+after")
+	}
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+
+	return string(b)
+}
+
+// backupsOf returns the backups of the log file at path, oldest first.
+func backupsOf(t *testing.T, path string) []string {
+	t.Helper()
+
+	ext := filepath.Ext(path)
+	base := path[:len(path)-len(ext)]
+
+	matches, err := filepath.Glob(base + "-*" + ext)
+	if err != nil {
+		t.Fatalf("failed to list backups: %v", err)
+	}
+
+	var backups []string
+	for _, match := range matches {
+		if isBackupOf(base, ext, match) {
+			backups = append(backups, match)
+		}
+	}
+
+	slices.Sort(backups)
+
+	return backups
+}
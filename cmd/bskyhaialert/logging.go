@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	LOG_FORMAT_TEXT = "text"
+	LOG_FORMAT_JSON = "json"
+
+	LOG_LEVEL = "info"
+)
+
+// logFile is the file the logs are written to, if the config has one.
+var logFile *RotatingFile
+
+// setupLogging makes the default logger write in the format and at the level of the config,
+// tagging every record with the handle of the account.
+// The logs are written to the rotating log file of the config if any, or to stderr.
+func setupLogging(cfg *Config) error {
+	var (
+		w    io.Writer = os.Stderr
+		file *RotatingFile
+	)
+
+	if cfg.LogFile != "" {
+		if cfg.LogMaxSize <= 0 {
+			return xerrors.Errorf("log_max_size must be positive, got %d", cfg.LogMaxSize)
+		}
+
+		var err error
+		file, err = openRotatingFile(cfg.LogFile, int64(cfg.LogMaxSize)<<20, time.Duration(cfg.LogMaxAge)*24*time.Hour, cfg.LogMaxBackups)
+		if err != nil {
+			return xerrors.Errorf("failed to open log file: %w", err)
+		}
+
+		w = file
+	}
+
+	handler, err := newLogHandler(cfg, w)
+	if err != nil {
+		if file != nil {
+			file.Close()
+		}
+
+		return err
+	}
+
+	slog.SetDefault(slog.New(handler).With("handle", cfg.Handle))
+
+	// The file of the previous config is closed once the default logger no longer writes to it.
+	if logFile != nil {
+		logFile.Close()
+	}
+
+	logFile = file
+
+	return nil
+}
+
+// newLogHandler returns the handler writing the logs to w in the format and at the level of the config.
+func newLogHandler(cfg *Config, w io.Writer) (slog.Handler, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		return nil, xerrors.Errorf("failed to parse log level: %w", err)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch cfg.LogFormat {
+	case LOG_FORMAT_TEXT:
+		return slog.NewTextHandler(w, opts), nil
+	case LOG_FORMAT_JSON:
+		return slog.NewJSONHandler(w, opts), nil
+	default:
+		return nil, xerrors.Errorf("unknown log format: %s", cfg.LogFormat)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	SMTP_PORT = 587
+
+	// SMTP_TLS_STARTTLS upgrades the connection when the server supports it.
+	SMTP_TLS_STARTTLS = "starttls"
+	// SMTP_TLS_IMPLICIT connects over TLS from the start, usually on port 465.
+	SMTP_TLS_IMPLICIT = "tls"
+	SMTP_TLS_NONE     = "none"
+
+	// CHART_CONTENT_ID identifies the chart attached to an HTML mail.
+	CHART_CONTENT_ID = "chart@bskyhaialert"
+)
+
+// Mailer sends reports by mail through an SMTP server.
+type Mailer struct {
+	host     string
+	port     int
+	tls      string
+	username string
+	password string
+	from     string
+	to       []string
+
+	// html sends the reports as HTML with the chart inline, if any.
+	html bool
+}
+
+func newMailer(cfg *Config) (*Mailer, error) {
+	switch cfg.SmtpTls {
+	case SMTP_TLS_STARTTLS, SMTP_TLS_IMPLICIT, SMTP_TLS_NONE:
+	default:
+		return nil, xerrors.Errorf("unknown smtp tls mode: %s", cfg.SmtpTls)
+	}
+
+	if cfg.SmtpFrom == "" || len(cfg.SmtpTo) == 0 {
+		return nil, xerrors.New("smtp_from and smtp_to are required")
+	}
+
+	return &Mailer{
+		host:     cfg.SmtpHost,
+		port:     cfg.SmtpPort,
+		tls:      cfg.SmtpTls,
+		username: cfg.SmtpUsername,
+		password: cfg.SmtpPassword,
+		from:     cfg.SmtpFrom,
+		to:       cfg.SmtpTo,
+		html:     cfg.SmtpHtml,
+	}, nil
+}
+
+// send mails the report under the subject. The chart is only attached to HTML mails and may be nil.
+func (m *Mailer) send(subject, text string, chart []byte) error {
+	msg, err := m.buildMessage(subject, text, chart)
+	if err != nil {
+		return xerrors.Errorf("failed to build message: %w", err)
+	}
+
+	if dryRun {
+		slog.Info("dry run: mail", "subject", subject, "to", strings.Join(m.to, ", "))
+		return nil
+	}
+
+	addr := net.JoinHostPort(m.host, strconv.Itoa(m.port))
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	switch m.tls {
+	case SMTP_TLS_IMPLICIT:
+		conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: m.host})
+		if err != nil {
+			return xerrors.Errorf("failed to connect: %w", err)
+		}
+
+		client, err := smtp.NewClient(conn, m.host)
+		if err != nil {
+			conn.Close()
+			return xerrors.Errorf("failed to create client: %w", err)
+		}
+
+		defer client.Close()
+
+		if err := m.deliver(client, auth, msg); err != nil {
+			return xerrors.Errorf("failed to deliver: %w", err)
+		}
+	case SMTP_TLS_NONE:
+		client, err := smtp.Dial(addr)
+		if err != nil {
+			return xerrors.Errorf("failed to connect: %w", err)
+		}
+
+		defer client.Close()
+
+		if err := m.deliver(client, auth, msg); err != nil {
+			return xerrors.Errorf("failed to deliver: %w", err)
+		}
+	default:
+		if err := smtp.SendMail(addr, auth, m.from, m.to, msg); err != nil {
+			return xerrors.Errorf("failed to send mail: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *Mailer) deliver(client *smtp.Client, auth smtp.Auth, msg []byte) error {
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return xerrors.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.from); err != nil {
+		return xerrors.Errorf("failed to set sender: %w", err)
+	}
+
+	for _, to := range m.to {
+		if err := client.Rcpt(to); err != nil {
+			return xerrors.Errorf("failed to add recipient: %w", err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return xerrors.Errorf("failed to start data: %w", err)
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return xerrors.Errorf("failed to write message: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return xerrors.Errorf("failed to end data: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func (m *Mailer) buildMessage(subject, text string, chart []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	fmt.Fprintf(buf, "From: %s\r\n", m.from)
+	fmt.Fprintf(buf, "To: %s\r\n", strings.Join(m.to, ", "))
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(buf, "MIME-Version: 1.0\r\n")
+
+	if !m.html {
+		fmt.Fprintf(buf, "Content-Type: text/plain; charset=utf-8\r\n")
+		fmt.Fprintf(buf, "Content-Transfer-Encoding: base64\r\n\r\n")
+		writeBase64(buf, []byte(text))
+
+		return buf.Bytes(), nil
+	}
+
+	body := "<pre>" + html.EscapeString(text) + "</pre>"
+	if chart != nil {
+		body += fmt.Sprintf(`<p><img src="cid:%s" alt="chart"></p>`, CHART_CONTENT_ID)
+	}
+
+	w := multipart.NewWriter(buf)
+	fmt.Fprintf(buf, "Content-Type: multipart/related; boundary=%s\r\n\r\n", w.Boundary())
+
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/html; charset=utf-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create html part: %w", err)
+	}
+
+	writeBase64(part, []byte("<html><body>"+body+"</body></html>"))
+
+	if chart != nil {
+		part, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {"image/png"},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Id":                {"<" + CHART_CONTENT_ID + ">"},
+			"Content-Disposition":       {`inline; filename="chart.png"`},
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("failed to create chart part: %w", err)
+		}
+
+		writeBase64(part, chart)
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, xerrors.Errorf("failed to close multipart writer: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeBase64 writes b encoded in base64 with lines of 76 characters, as required by MIME.
+func writeBase64(w io.Writer, b []byte) {
+	encoded := base64.StdEncoding.EncodeToString(b)
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+
+	w.Write([]byte(encoded + "\r\n"))
+}
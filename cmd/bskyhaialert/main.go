@@ -0,0 +1,143 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	ISO8601     = "2006-01-02T15:04:05.000Z"
+	POST_FORMAT = `【{{ if gt .Days 1 }}{{ .Since }}〜{{ end }}{{ .Yesterday }}の統計】
+ポスト数: {{ .PostsCount }}({{ formatDiff .PostsCountDiff }})
+フォロー数: {{ .FollowsCount }}({{ formatDiff .FollowsCountDiff }})
+フォロワー数: {{ .FollowersCount }}({{ formatDiff .FollowersCountDiff }})){{ if .Engagement }}
+いいね: {{ .LikesReceived }}({{ formatDiff .LikesReceivedDiff }})
+リポスト: {{ .RepostsReceived }}({{ formatDiff .RepostsReceivedDiff }})
+リプライ: {{ .RepliesReceived }}({{ formatDiff .RepliesReceivedDiff }})
+エンゲージメント率: {{ printf "%.2f" .EngagementRate }}%{{ end }}{{ if .PostBreakdown }}
+内訳: ポスト{{ .OriginalsCount }}({{ formatDiff .OriginalsCountDiff }}) リプライ{{ .RepliesCount }}({{ formatDiff .RepliesCountDiff }}) リポスト{{ .RepostsCount }}({{ formatDiff .RepostsCountDiff }}){{ end }}{{ if .FollowerSnapshot }}
+新規フォロワー: {{ .FollowersGained }} フォロー解除: {{ .FollowersLost }}{{ range .UnfollowerHandles }}
+・{{ . }}{{ end }}{{ end }}{{ if .Mutuals }}
+相互フォロー: {{ .MutualsCount }} 片思い: {{ .NonMutualFollowsCount }}{{ end }}{{ with .Activity }}
+アクティビティ: いいね{{ .Likes }} リポスト{{ .Reposts }} フォロー{{ .Follows }} メンション{{ .Mentions }} リプライ{{ .Replies }} 引用{{ .Quotes }}{{ end }}{{ range .Watchlist }}
+vs {{ .Handle }}: フォロワー数{{ .FollowersCount }}({{ formatDiff .FollowersCountDiff }}){{ end }}{{ with .TopPost }}
+人気ポスト: いいね{{ .Likes }}件{{ end }}`
+)
+
+type Param struct {
+	Yesterday string
+	// Since is the first day the diffs cover, which is before Yesterday if the previous runs were missed.
+	Since string
+	// Days is the number of days the diffs cover, from Since to Yesterday.
+	Days int
+
+	PostsCount            int64
+	PostsCountDiff        int64
+	FollowsCount          int64
+	FollowsCountDiff      int64
+	FollowersCount        int64
+	FollowersCountDiff    int64
+	ListsCount            int64
+	ListsCountDiff        int64
+	FeedgensCount         int64
+	FeedgensCountDiff     int64
+	StarterPacksCount     int64
+	StarterPacksCountDiff int64
+	Labeler               bool
+	Ratio                 float64
+	RatioDiff             float64
+	RatioPrecision        int
+	Engagement            bool
+	LikesReceived         int64
+	LikesReceivedDiff     int64
+	RepostsReceived       int64
+	RepostsReceivedDiff   int64
+	RepliesReceived       int64
+	RepliesReceivedDiff   int64
+	EngagementRate        float64
+	InteractionsPerPost   float64
+	LikesGiven            int64
+	PostBreakdown         bool
+	OriginalsCount        int64
+	OriginalsCountDiff    int64
+	RepliesCount          int64
+	RepliesCountDiff      int64
+	RepostsCount          int64
+	RepostsCountDiff      int64
+	FollowerSnapshot      bool
+	FollowersGained       int64
+	FollowersLost         int64
+	UnfollowerHandles     []string
+	Mutuals               bool
+	MutualsCount          int64
+	NonMutualFollowsCount int64
+	Activity              *Activity
+	Watchlist             []*WatchParam
+	TopPost               *TopPost
+
+	// Metrics holds the metrics of the registered providers by name, such as {{ .Metrics.followers.Diff }}.
+	Metrics map[string]Metric
+}
+
+// commands are the subcommands of the binary by name.
+var commands = map[string]struct {
+	usage string
+	run   func(args []string) error
+}{
+	"run":             {"run the scheduler (default)", runCommand},
+	"post-now":        {"run the daily job right away", postNowCommand},
+	"fetch":           {"fetch the current stats and print them", fetchCommand},
+	"preview":         {"print the daily post without posting it", previewCommand},
+	"history":         {"print the recorded stats", historyCommand},
+	"validate-config": {"check the config and its templates", validateConfigCommand},
+	"login":           {"log in and save the session", loginCommand},
+	"setup":           {"write a config interactively", setupCommand},
+}
+
+func main() {
+	name := "run"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	command, ok := commands[name]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := command.run(args); err != nil {
+		slog.Error("failed to run command", "command", name, "error", fmt.Sprintf("%+v", err))
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [flags]\n\ncommands:\n", os.Args[0])
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", name, commands[name].usage)
+	}
+}
+
+// newFlagSet returns the flags of the command with the ones every command accepts, including the keys of the config.
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	path := fs.String("config", CONFIG_PATH, "path of the config file in JSON, YAML or TOML")
+	fs.BoolVar(&dryRun, "dry-run", false, "log the writes instead of executing them")
+	fs.StringVar(&dataDir, "data-dir", defaultDataDir(), "directory of the sessions and the stats")
+	addConfigFlags(fs)
+
+	return fs, path
+}
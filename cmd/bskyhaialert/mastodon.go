@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	MASTODON_STATUSES_PATH = "/api/v1/statuses"
+	MASTODON_VISIBILITY    = "unlisted"
+	// MASTODON_POST_FORMAT fits the 500 characters of Mastodon, so it also carries the counters
+	// that are left out of the Bluesky post.
+	MASTODON_POST_FORMAT = `【{{ .Yesterday }}のBluesky統計】
+ポスト数: {{ .PostsCount }}({{ formatDiff .PostsCountDiff }})
+フォロー数: {{ .FollowsCount }}({{ formatDiff .FollowsCountDiff }})
+フォロワー数: {{ .FollowersCount }}({{ formatDiff .FollowersCountDiff }})
+リスト数: {{ .ListsCount }}({{ formatDiff .ListsCountDiff }})
+フィード数: {{ .FeedgensCount }}({{ formatDiff .FeedgensCountDiff }})
+スターターパック数: {{ .StarterPacksCount }}({{ formatDiff .StarterPacksCountDiff }}){{ if .Engagement }}
+いいね: {{ .LikesReceived }}({{ formatDiff .LikesReceivedDiff }})
+リポスト: {{ .RepostsReceived }}({{ formatDiff .RepostsReceivedDiff }})
+リプライ: {{ .RepliesReceived }}({{ formatDiff .RepliesReceivedDiff }})
+エンゲージメント率: {{ printf "%.2f" .EngagementRate }}%{{ end }}
+
+#Bluesky`
+)
+
+type mastodonStatus struct {
+	Status     string `json:"status"`
+	Visibility string `json:"visibility"`
+}
+
+// sendMastodon posts the rendered report as a status to the Mastodon instance.
+// The Idempotency-Key keeps a retried request from posting the report twice.
+func sendMastodon(ctx context.Context, instance, token, visibility, text string, param *Param) error {
+	status := &mastodonStatus{Status: text, Visibility: visibility}
+
+	header := map[string]string{
+		"Authorization":   "Bearer " + token,
+		"Idempotency-Key": "bskyhaialert-" + param.Yesterday,
+	}
+
+	u := strings.TrimSuffix(instance, "/") + MASTODON_STATUSES_PATH
+	if err := postJSONWithHeader(ctx, u, status, header); err != nil {
+		return xerrors.Errorf("failed to post status: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+const MATRIX_SEND_PATH = "/_matrix/client/v3/rooms/%s/send/m.room.message/%s"
+
+type matrixMessage struct {
+	MsgType string `json:"msgtype"`
+	Body    string `json:"body"`
+}
+
+// sendMatrix sends the rendered report to the Matrix room through the client-server API of the homeserver.
+// The transaction ID is derived from the day of the report, so that the homeserver drops a retried request
+// it has already accepted instead of sending the report twice.
+func sendMatrix(ctx context.Context, homeserver, token, roomId, text string, param *Param) error {
+	b, err := json.Marshal(&matrixMessage{MsgType: "m.text", Body: text})
+	if err != nil {
+		return xerrors.Errorf("failed to marshal message: %w", err)
+	}
+
+	txnId := "bskyhaialert-" + param.Yesterday
+	u := strings.TrimSuffix(homeserver, "/") + fmt.Sprintf(MATRIX_SEND_PATH, url.PathEscape(roomId), url.PathEscape(txnId))
+
+	header := map[string]string{"Authorization": "Bearer " + token}
+
+	if err := sendBody(ctx, http.MethodPut, u, "application/json", b, header); err != nil {
+		return xerrors.Errorf("failed to send message: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSendMatrixTxnId(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+	}))
+	defer server.Close()
+
+	// A retry of the same report reuses the transaction ID, so the homeserver drops it if the first one went through.
+	for range 2 {
+		if err := sendMatrix(context.Background(), server.URL, "token", "!room:example.org", "text", &Param{Yesterday: "2026-01-01"}); err != nil {
+			t.Fatalf("sendMatrix() error = %v", err)
+		}
+	}
+
+	want := "/_matrix/client/v3/rooms/%21room:example.org/send/m.room.message/bskyhaialert-2026-01-01"
+	for _, path := range paths {
+		if path != want {
+			t.Errorf("path = %s, want %s", path, want)
+		}
+	}
+
+	if len(paths) != 2 {
+		t.Errorf("sent %d requests, want 2", len(paths))
+	}
+}
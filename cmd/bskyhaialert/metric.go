@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/bluesky-social/indigo/xrpc"
+	"golang.org/x/xerrors"
+)
+
+// Metric is the value of a metric along with its difference from the day before.
+type Metric struct {
+	Value int64 `json:"value"`
+	Diff  int64 `json:"diff"`
+}
+
+// MetricEnv is what the metric providers collect the metrics from.
+// The providers collect the data of their features themselves and set it to Param for the templates,
+// so that a new metric does not need changes to the jobs.
+type MetricEnv struct {
+	Client  *xrpc.Client
+	Store   *Store
+	Config  *Config
+	Now     time.Time
+	Today   time.Time
+	Data    Data
+	NewData Data
+	Param   *Param
+
+	// Daily is set for the daily job. The providers that record snapshots, such as the followers,
+	// only collect for it, so that the other reports leave the snapshots of the day alone.
+	Daily bool
+
+	// TopPosts are the top posts of the day before today, set by the feed provider for the daily post.
+	TopPosts []*TopPost
+}
+
+// MetricProvider provides metrics to the report by name.
+// A provider that has nothing to provide, for instance because its feature is disabled, returns nil.
+type MetricProvider interface {
+	Name() string
+	Collect(ctx context.Context, env *MetricEnv) (map[string]Metric, error)
+}
+
+var metricProviders []MetricProvider
+
+// registerMetricProvider adds the provider to the ones collected on every run.
+// It is meant to be called from init functions.
+func registerMetricProvider(provider MetricProvider) {
+	metricProviders = append(metricProviders, provider)
+}
+
+// The providers are collected in the order they are registered.
+func init() {
+	registerMetricProvider(statsMetricProvider{})
+	registerMetricProvider(churnMetricProvider{})
+	registerMetricProvider(watchlistMetricProvider{})
+	registerMetricProvider(engagementMetricProvider{})
+	registerMetricProvider(likesGivenMetricProvider{})
+	registerMetricProvider(feedMetricProvider{})
+}
+
+// selectMetricProviders returns the registered providers of the names, or all of them if names is empty.
+func selectMetricProviders(names []string) ([]MetricProvider, error) {
+	if len(names) == 0 {
+		return metricProviders, nil
+	}
+
+	providers := make([]MetricProvider, 0, len(names))
+	for _, name := range names {
+		i := slices.IndexFunc(metricProviders, func(provider MetricProvider) bool { return provider.Name() == name })
+		if i < 0 {
+			return nil, xerrors.Errorf("unknown metric provider: %s", name)
+		}
+
+		providers = append(providers, metricProviders[i])
+	}
+
+	return providers, nil
+}
+
+// collectMetrics collects the metrics of the providers.
+// A provider that fails is logged and skipped.
+func collectMetrics(ctx context.Context, providers []MetricProvider, env *MetricEnv) map[string]Metric {
+	metrics := make(map[string]Metric)
+
+	for _, provider := range providers {
+		collected, err := provider.Collect(ctx, env)
+		if err != nil {
+			slog.Error("failed to collect metrics", "provider", provider.Name(), "error", err)
+			continue
+		}
+
+		for name, metric := range collected {
+			metrics[name] = metric
+		}
+	}
+
+	return metrics
+}
+
+// statsMetricProvider provides the counters of the profile.
+type statsMetricProvider struct{}
+
+func (statsMetricProvider) Name() string { return "stats" }
+
+func (statsMetricProvider) Collect(ctx context.Context, env *MetricEnv) (map[string]Metric, error) {
+	metric := func(before, after int64) Metric {
+		return Metric{Value: before, Diff: after - before}
+	}
+
+	return map[string]Metric{
+		"posts":         metric(env.Data.Posts, env.NewData.Posts),
+		"follows":       metric(env.Data.Follows, env.NewData.Follows),
+		"followers":     metric(env.Data.Followers, env.NewData.Followers),
+		"lists":         metric(env.Data.Lists, env.NewData.Lists),
+		"feedgens":      metric(env.Data.Feedgens, env.NewData.Feedgens),
+		"starter_packs": metric(env.Data.StarterPacks, env.NewData.StarterPacks),
+	}, nil
+}
+
+// churnMetricProvider records the followers of the day and provides the followers gained and lost,
+// if the follower snapshot is enabled. It also records the follows for the mutuals and writes the reports of them.
+type churnMetricProvider struct{}
+
+func (churnMetricProvider) Name() string { return "churn" }
+
+func (churnMetricProvider) Collect(ctx context.Context, env *MetricEnv) (map[string]Metric, error) {
+	if !env.Daily || !env.Config.FollowerSnapshot {
+		return nil, nil
+	}
+
+	followers, churn, err := updateFollowerSnapshot(ctx, env.Client, env.Store, env.Today, env.Param)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to update follower snapshot: %w", err)
+	}
+
+	if env.Config.ChurnReport && churn != nil {
+		path, err := writeJSONReport("churn", env.Today, churn)
+		if err != nil {
+			slog.Error("failed to write churn report", "error", err)
+		} else {
+			slog.Info("churn report written", "path", path)
+		}
+	}
+
+	if env.Config.Mutuals {
+		if err := updateMutuals(ctx, env.Client, env.Store, env.Today, followers, env.Param); err != nil {
+			slog.Error("failed to update mutuals", "error", err)
+		} else if env.Config.NonFollowback && env.Now.Weekday() == time.Monday {
+			path, err := writeNonFollowbackReport(env.Store, env.Today)
+			if err != nil {
+				slog.Error("failed to write non-followback report", "error", err)
+			} else {
+				slog.Info("non-followback report written", "path", path)
+			}
+		}
+	}
+
+	if !env.Config.ShowUnfollowers {
+		env.Param.UnfollowerHandles = nil
+	}
+
+	// Nothing is gained or lost without a previous snapshot.
+	if !env.Param.FollowerSnapshot {
+		return nil, nil
+	}
+
+	return map[string]Metric{
+		"followers_gained": {Value: env.Param.FollowersGained},
+		"followers_lost":   {Value: env.Param.FollowersLost},
+	}, nil
+}
+
+// watchlistMetricProvider records the stats of the watched accounts and sets them to the param
+// if they are compared. It provides no metrics of its own.
+type watchlistMetricProvider struct{}
+
+func (watchlistMetricProvider) Name() string { return "watchlist" }
+
+func (watchlistMetricProvider) Collect(ctx context.Context, env *MetricEnv) (map[string]Metric, error) {
+	if !env.Daily || len(env.Config.Watchlist) == 0 {
+		return nil, nil
+	}
+
+	watchlist := updateWatchlist(ctx, env.Client, env.Store, env.Now, env.Config.Watchlist)
+	if env.Config.WatchlistCompare {
+		env.Param.Watchlist = watchlist
+	}
+
+	return nil, nil
+}
+
+// engagementMetricProvider counts the notifications of the day before today and provides the interactions received,
+// if the engagement is enabled. The same counts make the activity section, if it is enabled.
+type engagementMetricProvider struct{}
+
+func (engagementMetricProvider) Name() string { return "engagement" }
+
+func (engagementMetricProvider) Collect(ctx context.Context, env *MetricEnv) (map[string]Metric, error) {
+	if !env.Daily || !(env.Config.Engagement || env.Config.Activity) {
+		return nil, nil
+	}
+
+	counts, prev, err := fetchNotificationCounts(ctx, env.Client, env.Store, env.Today)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to count notifications: %w", err)
+	}
+
+	if env.Config.Activity {
+		env.Param.Activity = newActivity(counts)
+	}
+
+	if !env.Config.Engagement {
+		return nil, nil
+	}
+
+	updateEngagement(counts, prev, env.Param)
+
+	return map[string]Metric{
+		"likes_received":   {Value: env.Param.LikesReceived, Diff: env.Param.LikesReceivedDiff},
+		"reposts_received": {Value: env.Param.RepostsReceived, Diff: env.Param.RepostsReceivedDiff},
+		"replies_received": {Value: env.Param.RepliesReceived, Diff: env.Param.RepliesReceivedDiff},
+	}, nil
+}
+
+// likesGivenMetricProvider provides the likes given on the day before today, if they are counted.
+type likesGivenMetricProvider struct{}
+
+func (likesGivenMetricProvider) Name() string { return "likes_given" }
+
+func (likesGivenMetricProvider) Collect(ctx context.Context, env *MetricEnv) (map[string]Metric, error) {
+	if !env.Daily || !env.Config.LikesGiven {
+		return nil, nil
+	}
+
+	likes, err := countLikesGiven(ctx, env.Client, env.Today.AddDate(0, 0, -1), env.Today)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to count likes given: %w", err)
+	}
+
+	env.Param.LikesGiven = likes
+
+	return map[string]Metric{"likes_given": {Value: likes}}, nil
+}
+
+// feedMetricProvider fetches the feed of the day before today for the top posts and provides the breakdown
+// of the posts, if it is enabled.
+type feedMetricProvider struct{}
+
+func (feedMetricProvider) Name() string { return "feed" }
+
+func (feedMetricProvider) Collect(ctx context.Context, env *MetricEnv) (map[string]Metric, error) {
+	if !env.Daily || !(env.Config.QuoteTopPost || env.Config.TopPostsReply || env.Config.PostBreakdown) {
+		return nil, nil
+	}
+
+	items, err := fetchFeedBetween(ctx, env.Client, env.Today.AddDate(0, 0, -1), env.Today)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to fetch feed: %w", err)
+	}
+
+	env.TopPosts = topPosts(postsOf(items), TOP_POSTS_COUNT)
+	if env.Config.QuoteTopPost && len(env.TopPosts) > 0 {
+		env.Param.TopPost = env.TopPosts[0]
+	}
+
+	if !env.Config.PostBreakdown {
+		return nil, nil
+	}
+
+	if err := updatePostBreakdown(env.Store, env.Today, items, env.Param); err != nil {
+		return nil, xerrors.Errorf("failed to update post breakdown: %w", err)
+	}
+
+	return map[string]Metric{
+		"originals": {Value: env.Param.OriginalsCount, Diff: env.Param.OriginalsCountDiff},
+		"replies":   {Value: env.Param.RepliesCount, Diff: env.Param.RepliesCountDiff},
+		"reposts":   {Value: env.Param.RepostsCount, Diff: env.Param.RepostsCountDiff},
+	}, nil
+}
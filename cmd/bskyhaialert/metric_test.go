@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSelectMetricProviders(t *testing.T) {
+	all, err := selectMetricProviders(nil)
+	if err != nil {
+		t.Fatalf("selectMetricProviders(nil) error = %v", err)
+	}
+
+	if len(all) != len(metricProviders) {
+		t.Errorf("selectMetricProviders(nil) = %d providers, want all %d", len(all), len(metricProviders))
+	}
+
+	selected, err := selectMetricProviders([]string{"engagement", "stats"})
+	if err != nil {
+		t.Fatalf("selectMetricProviders() error = %v", err)
+	}
+
+	if len(selected) != 2 || selected[0].Name() != "engagement" || selected[1].Name() != "stats" {
+		t.Errorf("selectMetricProviders() = %v, want engagement and stats", selected)
+	}
+
+	if _, err := selectMetricProviders([]string{"unknown"}); err == nil {
+		t.Error("selectMetricProviders() with an unknown name error = nil, want an error")
+	}
+}
+
+func TestCollectMetricsOutsideDailyJob(t *testing.T) {
+	// Outside the daily job, only the stats are collected even with every feature enabled,
+	// so no client or store is needed.
+	env := &MetricEnv{
+		Config: &Config{
+			FollowerSnapshot: true,
+			Mutuals:          true,
+			Watchlist:        []string{"alice.example"},
+			Engagement:       true,
+			Activity:         true,
+			LikesGiven:       true,
+			QuoteTopPost:     true,
+			PostBreakdown:    true,
+		},
+		Data:    Data{Posts: 10, Follows: 20, Followers: 30},
+		NewData: Data{Posts: 12, Follows: 20, Followers: 29},
+		Param:   &Param{},
+	}
+
+	got := collectMetrics(context.Background(), metricProviders, env)
+
+	want := map[string]Metric{
+		"posts":         {Value: 10, Diff: 2},
+		"follows":       {Value: 20, Diff: 0},
+		"followers":     {Value: 30, Diff: -1},
+		"lists":         {},
+		"feedgens":      {},
+		"starter_packs": {},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("collectMetrics() = %v, want %v", got, want)
+	}
+
+	if !reflect.DeepEqual(env.Param, &Param{}) {
+		t.Errorf("param = %+v, want it untouched", env.Param)
+	}
+}
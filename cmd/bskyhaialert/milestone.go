@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"text/template"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const MILESTONE_POST_FORMAT = `🎉 フォロワー数が{{ .Milestone }}人を突破しました！
+いつもありがとうございます！`
+
+// MILESTONES is the default list of follower counts worth announcing.
+var MILESTONES = []int64{1000, 5000, 10000, 50000, 100000, 500000, 1000000}
+
+type MilestoneParam struct {
+	Milestone      int64
+	FollowersCount int64
+}
+
+// postMilestone announces the highest follower milestone crossed between two snapshots
+// unless it has already been announced. milestones must be sorted in ascending order.
+func postMilestone(ctx context.Context, poster *Poster, store *Store, tmpl *template.Template, milestones []int64, now time.Time, before, after Data) error {
+	var crossed []int64
+	for _, milestone := range milestones {
+		if before.Followers >= milestone || after.Followers < milestone {
+			continue
+		}
+
+		announced, err := store.IsMilestoneAnnounced(milestone)
+		if err != nil {
+			return xerrors.Errorf("failed to check milestone: %w", err)
+		}
+
+		if !announced {
+			crossed = append(crossed, milestone)
+		}
+	}
+
+	if len(crossed) == 0 {
+		return nil
+	}
+
+	param := &MilestoneParam{
+		Milestone:      crossed[len(crossed)-1],
+		FollowersCount: after.Followers,
+	}
+
+	if err := poster.postTemplate(ctx, tmpl, param); err != nil {
+		return xerrors.Errorf("failed to post milestone: %w", err)
+	}
+
+	for _, milestone := range crossed {
+		if err := store.SaveMilestone(now, milestone); err != nil {
+			return xerrors.Errorf("failed to save milestone: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	MISSKEY_CREATE_NOTE_PATH = "/api/notes/create"
+	MISSKEY_VISIBILITY       = "home"
+)
+
+type misskeyNote struct {
+	Token      string `json:"i"`
+	Text       string `json:"text"`
+	Visibility string `json:"visibility"`
+}
+
+// sendMisskey posts the rendered report as a note to the Misskey instance with the API token.
+func sendMisskey(ctx context.Context, instance, token, visibility, text string) error {
+	note := &misskeyNote{Token: token, Text: text, Visibility: visibility}
+
+	u := strings.TrimSuffix(instance, "/") + MISSKEY_CREATE_NOTE_PATH
+	if err := postJSON(ctx, u, note); err != nil {
+		return xerrors.Errorf("failed to create note: %w", err)
+	}
+
+	return nil
+}
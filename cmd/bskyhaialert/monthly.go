@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"text/template"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// MONTH_FORMAT is the layout of the months.
+const MONTH_FORMAT = "2006-01"
+
+const MONTHLY_POST_FORMAT = `【{{ .Month }}の月間統計】
+ポスト数: {{ .PostsCount }}({{ formatDiff .PostsCountDiff }}, {{ printf "%+.1f" .PostsCountRate }}%)
+フォロー数: {{ .FollowsCount }}({{ formatDiff .FollowsCountDiff }}, {{ printf "%+.1f" .FollowsCountRate }}%)
+フォロワー数: {{ .FollowersCount }}({{ formatDiff .FollowersCountDiff }}, {{ printf "%+.1f" .FollowersCountRate }}%)`
+
+type MonthlyParam struct {
+	Month              string
+	PostsCount         int64
+	PostsCountDiff     int64
+	PostsCountRate     float64
+	FollowsCount       int64
+	FollowsCountDiff   int64
+	FollowsCountRate   float64
+	FollowersCount     int64
+	FollowersCountDiff int64
+	FollowersCountRate float64
+}
+
+// postMonthlyRecap posts a recap of the month before now.
+// The snapshot of now must already be saved.
+func postMonthlyRecap(ctx context.Context, poster *Poster, store *Store, tmpl *template.Template, now time.Time) error {
+	from := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, now.Location())
+
+	history, err := store.GetStatsBetween(from, now)
+	if err != nil {
+		return xerrors.Errorf("failed to get history: %w", err)
+	}
+
+	if len(history) < 2 {
+		return xerrors.New("not enough history")
+	}
+
+	first, last := history[0], history[len(history)-1]
+
+	param := &MonthlyParam{
+		Month:              from.Format(MONTH_FORMAT),
+		PostsCount:         last.Posts,
+		PostsCountDiff:     last.Posts - first.Posts,
+		PostsCountRate:     growthRate(first.Posts, last.Posts),
+		FollowsCount:       last.Follows,
+		FollowsCountDiff:   last.Follows - first.Follows,
+		FollowsCountRate:   growthRate(first.Follows, last.Follows),
+		FollowersCount:     last.Followers,
+		FollowersCountDiff: last.Followers - first.Followers,
+		FollowersCountRate: growthRate(first.Followers, last.Followers),
+	}
+
+	return poster.postTemplate(ctx, tmpl, param)
+}
+
+// growthRate returns the change from before to after as a percentage of before.
+func growthRate(before, after int64) float64 {
+	if before == 0 {
+		return 0
+	}
+
+	return float64(after-before) / float64(before) * 100
+}
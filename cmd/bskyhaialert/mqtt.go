@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"golang.org/x/xerrors"
+)
+
+const (
+	MQTT_TOPIC_PREFIX = "bsky"
+	MQTT_CLIENT_ID    = "bskyhaialert"
+	MQTT_TIMEOUT      = 10 * time.Second
+)
+
+// Mqtt publishes the metrics to an MQTT broker.
+type Mqtt struct {
+	opts *mqtt.ClientOptions
+	// prefix is the topic under which the metrics are published, such as bsky/foo.bsky.social.
+	prefix string
+	handle string
+	// discoveryPrefix is the Home Assistant discovery prefix. Discovery is disabled if it is empty.
+	discoveryPrefix string
+}
+
+func newMqtt(broker, clientId, username, password, prefix, handle, discoveryPrefix string) *Mqtt {
+	opts := mqtt.NewClientOptions().
+		AddBroker(broker).
+		SetClientID(clientId).
+		SetUsername(username).
+		SetPassword(password).
+		SetConnectTimeout(MQTT_TIMEOUT)
+
+	return &Mqtt{opts: opts, prefix: fmt.Sprintf("%s/%s", prefix, handle), handle: handle, discoveryPrefix: discoveryPrefix}
+}
+
+// topic returns the topic of the metric, such as bsky/foo.bsky.social/followers.
+func (m *Mqtt) topic(metric string) string {
+	return fmt.Sprintf("%s/%s", m.prefix, metric)
+}
+
+// publishData publishes each counter of the data to its own retained topic,
+// so that subscribers receive the latest value as soon as they subscribe.
+func (m *Mqtt) publishData(data Data) error {
+	payloads := map[string]string{
+		m.topic("posts"):         strconv.FormatInt(data.Posts, 10),
+		m.topic("follows"):       strconv.FormatInt(data.Follows, 10),
+		m.topic("followers"):     strconv.FormatInt(data.Followers, 10),
+		m.topic("lists"):         strconv.FormatInt(data.Lists, 10),
+		m.topic("feedgens"):      strconv.FormatInt(data.Feedgens, 10),
+		m.topic("starter_packs"): strconv.FormatInt(data.StarterPacks, 10),
+		m.topic("labeler"):       strconv.FormatBool(data.Labeler),
+	}
+
+	if m.discoveryPrefix != "" {
+		if err := m.addDiscoveryConfigs(payloads); err != nil {
+			return xerrors.Errorf("failed to add discovery configs: %w", err)
+		}
+	}
+
+	return m.publish(payloads)
+}
+
+// publish connects to the broker and publishes the payloads keyed by topic as retained messages.
+func (m *Mqtt) publish(payloads map[string]string) error {
+	if dryRun {
+		for topic, payload := range payloads {
+			slog.Info("dry run: publish", "topic", topic, "payload", payload)
+		}
+
+		return nil
+	}
+
+	client := mqtt.NewClient(m.opts)
+
+	token := client.Connect()
+	if !token.WaitTimeout(MQTT_TIMEOUT) {
+		return xerrors.New("timed out connecting to broker")
+	}
+
+	if err := token.Error(); err != nil {
+		return xerrors.Errorf("failed to connect to broker: %w", err)
+	}
+
+	defer client.Disconnect(250)
+
+	for topic, payload := range payloads {
+		token := client.Publish(topic, 1, true, payload)
+		if !token.WaitTimeout(MQTT_TIMEOUT) {
+			return xerrors.Errorf("timed out publishing %s", topic)
+		}
+
+		if err := token.Error(); err != nil {
+			return xerrors.Errorf("failed to publish %s: %w", topic, err)
+		}
+	}
+
+	return nil
+}
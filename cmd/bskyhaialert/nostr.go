@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/gorilla/websocket"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// NOSTR_PRIVATE_KEY_ENV is read for the private key when nostr_private_key is empty.
+	NOSTR_PRIVATE_KEY_ENV = "NOSTR_PRIVATE_KEY"
+	NOSTR_KIND_TEXT_NOTE  = 1
+	NOSTR_TIMEOUT         = 10 * time.Second
+
+	// NOSTR_NSEC_PREFIX is the human-readable part of the bech32 encoded private keys as per NIP-19.
+	NOSTR_NSEC_PREFIX = "nsec"
+	BECH32_CHARSET    = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+type nostrEvent struct {
+	Id        string     `json:"id"`
+	PubKey    string     `json:"pubkey"`
+	CreatedAt int64      `json:"created_at"`
+	Kind      int        `json:"kind"`
+	Tags      [][]string `json:"tags"`
+	Content   string     `json:"content"`
+	Sig       string     `json:"sig"`
+}
+
+// parseNostrPrivateKey decodes the private key, encoded in hex or in bech32 with NOSTR_NSEC_PREFIX.
+func parseNostrPrivateKey(s string) (*btcec.PrivateKey, error) {
+	var b []byte
+	if strings.HasPrefix(strings.ToLower(s), NOSTR_NSEC_PREFIX+"1") {
+		hrp, data, err := decodeBech32(s)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode nsec: %w", err)
+		}
+
+		if hrp != NOSTR_NSEC_PREFIX {
+			return nil, xerrors.Errorf("unexpected prefix %s", hrp)
+		}
+
+		b = data
+	} else {
+		var err error
+		b, err = hex.DecodeString(s)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode hex: %w", err)
+		}
+	}
+
+	if len(b) != 32 {
+		return nil, xerrors.Errorf("private key must be 32 bytes, got %d", len(b))
+	}
+
+	var scalar btcec.ModNScalar
+	if overflow := scalar.SetByteSlice(b); overflow || scalar.IsZero() {
+		return nil, xerrors.New("private key is out of range")
+	}
+
+	key, _ := btcec.PrivKeyFromBytes(b)
+
+	return key, nil
+}
+
+// decodeBech32 decodes the bech32 string as per BIP-173, returning its human-readable part and its data.
+func decodeBech32(s string) (string, []byte, error) {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, xerrors.New("mixed case")
+	}
+
+	s = strings.ToLower(s)
+
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || len(s)-sep-1 < 6 {
+		return "", nil, xerrors.New("invalid separator position")
+	}
+
+	hrp := s[:sep]
+
+	values := make([]byte, 0, len(hrp)*2+1+len(s)-sep-1)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]>>5)
+	}
+
+	values = append(values, 0)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]&31)
+	}
+
+	data := make([]byte, 0, len(s)-sep-1)
+	for _, c := range s[sep+1:] {
+		v := strings.IndexRune(BECH32_CHARSET, c)
+		if v < 0 {
+			return "", nil, xerrors.Errorf("invalid character %q", c)
+		}
+
+		data = append(data, byte(v))
+	}
+
+	if bech32Polymod(append(values, data...)) != 1 {
+		return "", nil, xerrors.New("invalid checksum")
+	}
+
+	// The 5-bit groups without the checksum are regrouped into bytes, dropping the padding.
+	var out []byte
+	var acc, bits uint
+	for _, v := range data[:len(data)-6] {
+		acc = acc<<5 | uint(v)
+		bits += 5
+
+		if bits >= 8 {
+			bits -= 8
+			out = append(out, byte(acc>>bits))
+			acc &= 1<<bits - 1
+		}
+	}
+
+	if bits >= 5 || acc&(1<<bits-1) != 0 {
+		return "", nil, xerrors.New("invalid padding")
+	}
+
+	return hrp, out, nil
+}
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+
+		for i := 0; i < 5; i++ {
+			if (b>>i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+
+	return chk
+}
+
+// newNostrNote creates a text note of the content signed with the private key as per NIP-01.
+// The key is encoded in hex or as an nsec.
+func newNostrNote(privateKey, content string, createdAt time.Time) (*nostrEvent, error) {
+	key, err := parseNostrPrivateKey(privateKey)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse private key: %w", err)
+	}
+
+	event := &nostrEvent{
+		PubKey:    hex.EncodeToString(schnorr.SerializePubKey(key.PubKey())),
+		CreatedAt: createdAt.Unix(),
+		Kind:      NOSTR_KIND_TEXT_NOTE,
+		Tags:      [][]string{},
+		Content:   content,
+	}
+
+	serialized, err := marshalNostr([]any{0, event.PubKey, event.CreatedAt, event.Kind, event.Tags, event.Content})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to serialize event: %w", err)
+	}
+
+	id := sha256.Sum256(serialized)
+	event.Id = hex.EncodeToString(id[:])
+
+	sig, err := schnorr.Sign(key, id[:])
+	if err != nil {
+		return nil, xerrors.Errorf("failed to sign event: %w", err)
+	}
+
+	event.Sig = hex.EncodeToString(sig.Serialize())
+
+	return event, nil
+}
+
+// marshalNostr encodes v as JSON without escaping HTML, which would change the event id.
+func marshalNostr(v any) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// sendNostr publishes the rendered report as a text note to each of the relays.
+// A relay that fails is logged and skipped, and it fails only if no relay accepts the note.
+func sendNostr(ctx context.Context, relays []string, privateKey, text string) error {
+	event, err := newNostrNote(privateKey, text, time.Now())
+	if err != nil {
+		return xerrors.Errorf("failed to create note: %w", err)
+	}
+
+	accepted := 0
+	for _, relay := range relays {
+		if err := publishNostrEvent(ctx, relay, event); err != nil {
+			slog.Error("failed to publish", "relay", relay, "error", err)
+			continue
+		}
+
+		accepted++
+	}
+
+	if accepted == 0 {
+		return xerrors.New("no relay accepted the note")
+	}
+
+	return nil
+}
+
+// publishNostrEvent sends the event to the relay and waits for its OK message.
+func publishNostrEvent(ctx context.Context, relay string, event *nostrEvent) error {
+	if dryRun {
+		slog.Info("dry run: publish note", "id", event.Id, "relay", relay)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, NOSTR_TIMEOUT)
+	defer cancel()
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, relay, nil)
+	if err != nil {
+		return xerrors.Errorf("failed to connect to relay: %w", err)
+	}
+
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	conn.SetReadDeadline(deadline)
+
+	if err := conn.WriteJSON([]any{"EVENT", event}); err != nil {
+		return xerrors.Errorf("failed to send event: %w", err)
+	}
+
+	for {
+		var message []json.RawMessage
+		if err := conn.ReadJSON(&message); err != nil {
+			return xerrors.Errorf("failed to read message: %w", err)
+		}
+
+		var typ, id string
+		if len(message) < 4 || json.Unmarshal(message[0], &typ) != nil || typ != "OK" {
+			continue
+		}
+
+		if json.Unmarshal(message[1], &id) != nil || id != event.Id {
+			continue
+		}
+
+		var ok bool
+		var reason string
+		json.Unmarshal(message[2], &ok)
+		json.Unmarshal(message[3], &reason)
+
+		if !ok {
+			return xerrors.Errorf("event rejected: %s", reason)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// The key of the NIP-19 examples, in hex and as an nsec.
+const (
+	testNostrKeyHex  = "67dea2ed018072d675f5415ecfaed7d2597555e202d85b3d65ea4e58d2d92ffa"
+	testNostrKeyNsec = "nsec1vl029mgpspedva04g90vltkh6fvh240zqtv9k0t9af8935ke9laqsnlfe5"
+)
+
+func TestParseNostrPrivateKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{"hex", testNostrKeyHex, false},
+		{"nsec", testNostrKeyNsec, false},
+		{"upper case nsec", strings.ToUpper(testNostrKeyNsec), false},
+		{"mixed case nsec", "Nsec" + strings.TrimPrefix(testNostrKeyNsec, "nsec"), true},
+		{"bad checksum", strings.TrimSuffix(testNostrKeyNsec, "5") + "6", true},
+		{"npub", "npub1vl029mgpspedva04g90vltkh6fvh240zqtv9k0t9af8935ke9laqu95glp", true},
+		{"zero", "nsec1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqwkhnav", true},
+		{"short nsec", "nsec1qqqqqqqqqqqqqqqqqqqqqqqqqqxacnkl", true},
+		{"short hex", testNostrKeyHex[:62], true},
+		{"not hex", strings.Repeat("zz", 32), true},
+		{"empty", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := parseNostrPrivateKey(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNostrPrivateKey(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			}
+
+			if err == nil && hex.EncodeToString(key.Serialize()) != testNostrKeyHex {
+				t.Errorf("parseNostrPrivateKey(%q) = %x, want %s", tt.key, key.Serialize(), testNostrKeyHex)
+			}
+		})
+	}
+}
+
+func TestNewNostrNote(t *testing.T) {
+	createdAt := time.Unix(1700000000, 0)
+	content := "<b>統計</b> & \"quotes\""
+
+	event, err := newNostrNote(testNostrKeyNsec, content, createdAt)
+	if err != nil {
+		t.Fatalf("newNostrNote() error = %v", err)
+	}
+
+	if event.Kind != NOSTR_KIND_TEXT_NOTE || event.CreatedAt != createdAt.Unix() || event.Content != content {
+		t.Errorf("event = %+v, want a text note of the content at %d", event, createdAt.Unix())
+	}
+
+	// The id is the hash of the serialization of NIP-01, with the HTML characters left as is.
+	serialized, err := marshalNostr([]any{0, event.PubKey, event.CreatedAt, event.Kind, event.Tags, event.Content})
+	if err != nil {
+		t.Fatalf("marshalNostr() error = %v", err)
+	}
+
+	if !strings.Contains(string(serialized), content[:len("<b>")]) {
+		t.Errorf("serialization %s escapes HTML", serialized)
+	}
+
+	id := sha256.Sum256(serialized)
+	if event.Id != hex.EncodeToString(id[:]) {
+		t.Errorf("Id = %s, want %x", event.Id, id)
+	}
+
+	pubKeyBytes, err := hex.DecodeString(event.PubKey)
+	if err != nil {
+		t.Fatalf("failed to decode pubkey: %v", err)
+	}
+
+	pubKey, err := schnorr.ParsePubKey(pubKeyBytes)
+	if err != nil {
+		t.Fatalf("failed to parse pubkey: %v", err)
+	}
+
+	sigBytes, err := hex.DecodeString(event.Sig)
+	if err != nil {
+		t.Fatalf("failed to decode sig: %v", err)
+	}
+
+	sig, err := schnorr.ParseSignature(sigBytes)
+	if err != nil {
+		t.Fatalf("failed to parse sig: %v", err)
+	}
+
+	if !sig.Verify(id[:], pubKey) {
+		t.Error("signature does not verify against the pubkey")
+	}
+
+	// The same key in hex makes the same event.
+	other, err := newNostrNote(testNostrKeyHex, content, createdAt)
+	if err != nil {
+		t.Fatalf("newNostrNote() with hex key error = %v", err)
+	}
+
+	if other.PubKey != event.PubKey || other.Id != event.Id {
+		t.Errorf("event with hex key = %+v, want the pubkey and id of %+v", other, event)
+	}
+}
+
+func TestMarshalNostr(t *testing.T) {
+	got, err := marshalNostr([]any{0, "<a>&'", 1})
+	if err != nil {
+		t.Fatalf("marshalNostr() error = %v", err)
+	}
+
+	if want := `[0,"<a>&'",1]`; string(got) != want {
+		t.Errorf("marshalNostr() = %s, want %s", got, want)
+	}
+}
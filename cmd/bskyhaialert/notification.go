@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/xrpc"
+	"golang.org/x/xerrors"
+)
+
+const NOTIFICATION_PAGE_SIZE = 100
+
+// Activity summarizes the notifications of a day by their types.
+type Activity struct {
+	Likes    int64
+	Reposts  int64
+	Follows  int64
+	Mentions int64
+	Replies  int64
+	Quotes   int64
+}
+
+// fetchNotificationsBetween returns the notifications indexed from one time to another, newest first.
+// If reasons is not empty, only notifications for those reasons are returned.
+func fetchNotificationsBetween(ctx context.Context, client *xrpc.Client, from, to time.Time, reasons []string) ([]*bsky.NotificationListNotifications_Notification, error) {
+	var notifications []*bsky.NotificationListNotifications_Notification
+
+	cursor := ""
+	for {
+		out, err := bsky.NotificationListNotifications(ctx, client, cursor, NOTIFICATION_PAGE_SIZE, false, reasons, "")
+		if err != nil {
+			return nil, xerrors.Errorf("failed to list notifications: %w", err)
+		}
+
+		for _, notification := range out.Notifications {
+			indexedAt, err := time.Parse(time.RFC3339, notification.IndexedAt)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to parse notification date: %w", err)
+			}
+
+			if indexedAt.Before(from) {
+				return notifications, nil
+			}
+
+			if indexedAt.Before(to) {
+				notifications = append(notifications, notification)
+			}
+		}
+
+		if out.Cursor == nil || len(out.Notifications) == 0 {
+			return notifications, nil
+		}
+
+		cursor = *out.Cursor
+	}
+}
+
+// countReasons counts the notifications by their reasons.
+func countReasons(notifications []*bsky.NotificationListNotifications_Notification) map[string]int64 {
+	counts := make(map[string]int64)
+	for _, notification := range notifications {
+		counts[notification.Reason]++
+	}
+
+	return counts
+}
+
+func newActivity(counts map[string]int64) *Activity {
+	return &Activity{
+		Likes:    counts["like"],
+		Reposts:  counts["repost"],
+		Follows:  counts["follow"],
+		Mentions: counts["mention"],
+		Replies:  counts["reply"],
+		Quotes:   counts["quote"],
+	}
+}
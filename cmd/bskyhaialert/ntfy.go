@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"mime"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	NTFY_PRIORITY_DEFAULT = "default"
+	NTFY_PRIORITY_HIGH    = "high"
+)
+
+// Ntfy publishes push notifications to a ntfy topic.
+type Ntfy struct {
+	// url is the URL of the topic, such as https://ntfy.sh/mytopic.
+	url   string
+	token string
+}
+
+func newNtfy(url, token string) *Ntfy {
+	return &Ntfy{url: url, token: token}
+}
+
+// publish sends the message to the topic with the title and priority.
+func (n *Ntfy) publish(ctx context.Context, title, message, priority string) error {
+	header := map[string]string{
+		"Title":    mime.BEncoding.Encode("utf-8", title),
+		"Priority": priority,
+	}
+
+	if n.token != "" {
+		header["Authorization"] = "Bearer " + n.token
+	}
+
+	if err := postBody(ctx, n.url, "text/plain; charset=utf-8", []byte(message), header); err != nil {
+		return xerrors.Errorf("failed to publish: %w", err)
+	}
+
+	return nil
+}
+
+// alert publishes the error with a high priority, logging if that fails too.
+func (n *Ntfy) alert(ctx context.Context, title string, err error) {
+	if err := n.publish(ctx, title, fmt.Sprintf("%v", err), NTFY_PRIORITY_HIGH); err != nil {
+		slog.Error("failed to publish alert", "error", err)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/lex/util"
+	"golang.org/x/xerrors"
+)
+
+// pin pins the post to the profile, replacing the previously pinned post.
+func (p *Poster) pin(ctx context.Context, ref *atproto.RepoStrongRef) error {
+	record, err := atproto.RepoGetRecord(ctx, p.client, "", "app.bsky.actor.profile", p.client.Auth.Did, "self")
+	if err != nil {
+		return xerrors.Errorf("failed to get profile: %w", err)
+	}
+
+	profile, ok := record.Value.Val.(*bsky.ActorProfile)
+	if !ok {
+		return xerrors.New("unexpected profile record")
+	}
+
+	profile.PinnedPost = ref
+
+	if _, err := atproto.RepoPutRecord(ctx, p.client, &atproto.RepoPutRecord_Input{
+		Collection: "app.bsky.actor.profile",
+		Repo:       p.client.Auth.Did,
+		Rkey:       "self",
+		Record: &util.LexiconTypeDecoder{
+			Val: profile,
+		},
+		SwapRecord: record.Cid,
+	}); err != nil {
+		return xerrors.Errorf("failed to update profile: %w", err)
+	}
+
+	return nil
+}
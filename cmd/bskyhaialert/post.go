@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"text/template"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/lex/util"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+	"golang.org/x/xerrors"
+)
+
+// LANGS is the default list of languages the posts are written in.
+var LANGS = []string{"ja"}
+
+// Poster creates posts on the account of its client.
+type Poster struct {
+	client *xrpc.Client
+	langs  []string
+
+	// threadgate restricts replies to the threads started by the poster.
+	// nil means that anyone can reply, while an empty list means that nobody can.
+	threadgate []*bsky.FeedThreadgate_Allow_Elem
+
+	// disableQuotes prevents the posts from being quoted.
+	disableQuotes bool
+}
+
+func newPoster(client *xrpc.Client, cfg *Config) (*Poster, error) {
+	threadgate, err := parseThreadgate(cfg.Threadgate)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse threadgate: %w", err)
+	}
+
+	return &Poster{
+		client:        client,
+		langs:         cfg.Langs,
+		threadgate:    threadgate,
+		disableQuotes: cfg.DisableQuotes,
+	}, nil
+}
+
+func (p *Poster) post(ctx context.Context, text string, embed *bsky.FeedPost_Embed, reply *bsky.FeedPost_ReplyRef) (*atproto.RepoCreateRecord_Output, error) {
+	out, err := atproto.RepoCreateRecord(ctx, p.client, &atproto.RepoCreateRecord_Input{
+		Collection: "app.bsky.feed.post",
+		Repo:       p.client.Auth.Did,
+		Record: &util.LexiconTypeDecoder{
+			Val: &bsky.FeedPost{
+				Text:      text,
+				Facets:    buildFacets(ctx, p.client, text),
+				Embed:     embed,
+				Reply:     reply,
+				Langs:     p.langs,
+				CreatedAt: time.Now().UTC().Format(ISO8601),
+			},
+		},
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create record: %w", err)
+	}
+
+	if p.disableQuotes {
+		if err := p.createPostgate(ctx, out.Uri); err != nil {
+			slog.Error("failed to disable quotes", "error", err)
+		}
+	}
+
+	return out, nil
+}
+
+func (p *Poster) postTemplate(ctx context.Context, tmpl *template.Template, param any) error {
+	text, err := bskystats.RenderTemplate(tmpl, param)
+	if err != nil {
+		return xerrors.Errorf("failed to render template: %w", err)
+	}
+
+	if _, err := p.postThread(ctx, []string{text}, nil, nil); err != nil {
+		return xerrors.Errorf("failed to post: %w", err)
+	}
+
+	return nil
+}
+
+// Thread refers to the posts created by postThread.
+type Thread struct {
+	// Head is the first post created.
+	Head *atproto.RepoStrongRef
+	// Reply is a reference for replying to the last post created.
+	Reply *bsky.FeedPost_ReplyRef
+	// Posted is the number of posts created.
+	Posted int
+}
+
+// postThread posts texts as a thread, attaching embed to the first post.
+// Texts longer than MAX_POST_LENGTH graphemes are split into several posts.
+// If reply is not nil, the thread continues from it.
+// On failure, the posts created so far are returned along with the error.
+func (p *Poster) postThread(ctx context.Context, texts []string, embed *bsky.FeedPost_Embed, reply *bsky.FeedPost_ReplyRef) (*Thread, error) {
+	return p.resumeThread(ctx, texts, embed, &Thread{Reply: reply})
+}
+
+// resumeThread posts the texts like postThread, skipping the posts already created in thread, so that
+// a thread that failed halfway is completed without posting its first posts again.
+func (p *Poster) resumeThread(ctx context.Context, texts []string, embed *bsky.FeedPost_Embed, thread *Thread) (*Thread, error) {
+	thread = &Thread{Head: thread.Head, Reply: thread.Reply, Posted: thread.Posted}
+	reply := thread.Reply
+
+	var chunks []string
+	for _, text := range texts {
+		chunks = append(chunks, splitText(text, MAX_POST_LENGTH)...)
+	}
+
+	for i, text := range chunks {
+		if i < thread.Posted {
+			continue
+		}
+
+		if i > 0 {
+			embed = nil
+		}
+
+		out, err := p.post(ctx, text, embed, reply)
+		if err != nil {
+			return thread, xerrors.Errorf("failed to post: %w", err)
+		}
+
+		ref := &atproto.RepoStrongRef{
+			Uri: out.Uri,
+			Cid: out.Cid,
+		}
+
+		if thread.Head == nil {
+			thread.Head = ref
+		}
+
+		root := ref
+		if reply != nil {
+			root = reply.Root
+		} else if p.threadgate != nil {
+			if err := p.createThreadgate(ctx, out.Uri, p.threadgate); err != nil {
+				slog.Error("failed to restrict replies", "error", err)
+			}
+		}
+
+		reply = &bsky.FeedPost_ReplyRef{
+			Root:   root,
+			Parent: ref,
+		}
+
+		thread.Reply = reply
+		thread.Posted++
+	}
+
+	return thread, nil
+}
@@ -0,0 +1,13 @@
+package main
+
+// RATIO_PRECISION is the default number of decimal places of the follower ratio.
+const RATIO_PRECISION = 2
+
+// ratio returns the number of followers per follow.
+func ratio(data Data) float64 {
+	if data.Follows == 0 {
+		return 0
+	}
+
+	return float64(data.Followers) / float64(data.Follows)
+}
@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestRatio(t *testing.T) {
+	tests := []struct {
+		data Data
+		want float64
+	}{
+		{Data{Followers: 150, Follows: 100}, 1.5},
+		{Data{Followers: 0, Follows: 10}, 0},
+		{Data{Followers: 10, Follows: 0}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := ratio(tt.data); got != tt.want {
+			t.Errorf("ratio(%+v) = %v, want %v", tt.data, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/xrpc"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// REPORT_DIR is the directory under dataDir the reports are written to.
+	REPORT_DIR    = "reports"
+	INACTIVE_DAYS = 30
+)
+
+// writeReport writes the lines to a file named after the report and the day under REPORT_DIR
+// and returns its path.
+func writeReport(name string, date time.Time, lines []string) (string, error) {
+	if err := os.MkdirAll(statePath(REPORT_DIR), 0o700); err != nil {
+		return "", xerrors.Errorf("failed to create report directory: %w", err)
+	}
+
+	path := filepath.Join(statePath(REPORT_DIR), name+"-"+date.Format(DATE_FORMAT)+".txt")
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+
+	if err := writeFile(path, []byte(b.String()), 0o600); err != nil {
+		return "", xerrors.Errorf("failed to write report: %w", err)
+	}
+
+	return path, nil
+}
+
+// writeJSONReport writes v as JSON to a file named after the report and the day under REPORT_DIR
+// and returns its path.
+func writeJSONReport(name string, date time.Time, v any) (string, error) {
+	if err := os.MkdirAll(statePath(REPORT_DIR), 0o700); err != nil {
+		return "", xerrors.Errorf("failed to create report directory: %w", err)
+	}
+
+	path := filepath.Join(statePath(REPORT_DIR), name+"-"+date.Format(DATE_FORMAT)+".json")
+
+	b, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return "", xerrors.Errorf("failed to marshal report: %w", err)
+	}
+
+	if err := writeFile(path, b, 0o600); err != nil {
+		return "", xerrors.Errorf("failed to write report: %w", err)
+	}
+
+	return path, nil
+}
+
+// writeNonFollowbackReport lists the accounts the account follows that do not follow back,
+// according to the graph snapshots as of today, and returns the path of the report.
+func writeNonFollowbackReport(store *Store, today time.Time) (string, error) {
+	tomorrow := today.AddDate(0, 0, 1)
+
+	followers, err := store.GetLatestGraphBefore(tomorrow, GRAPH_FOLLOWERS)
+	if err != nil {
+		return "", xerrors.Errorf("failed to get followers: %w", err)
+	}
+
+	follows, err := store.GetLatestGraphBefore(tomorrow, GRAPH_FOLLOWS)
+	if err != nil {
+		return "", xerrors.Errorf("failed to get follows: %w", err)
+	}
+
+	nonMutuals, _ := diffActors(followers, follows)
+
+	lines := make([]string, 0, len(nonMutuals))
+	for _, actor := range nonMutuals {
+		lines = append(lines, actor.Handle+"\t"+actor.Did)
+	}
+
+	return writeReport("non_followback", today, lines)
+}
+
+// writeInactiveFollowsReport lists the accounts the account follows that have not posted
+// in the days before now, and returns the path of the report.
+func writeInactiveFollowsReport(ctx context.Context, client *xrpc.Client, now time.Time, days int) (string, error) {
+	follows, err := fetchFollows(ctx, client)
+	if err != nil {
+		return "", xerrors.Errorf("failed to fetch follows: %w", err)
+	}
+
+	since := now.AddDate(0, 0, -days)
+
+	var lines []string
+	for _, actor := range follows {
+		last, err := lastPostedAt(ctx, client, actor.Did)
+		if err != nil {
+			slog.Error("failed to get last post", "actor", actor.Handle, "error", err)
+			continue
+		}
+
+		if last.Before(since) {
+			line := actor.Handle + "\t" + actor.Did + "\t"
+			if !last.IsZero() {
+				line += last.Format(DATE_FORMAT)
+			}
+
+			lines = append(lines, line)
+		}
+	}
+
+	return writeReport("inactive_follows", now, lines)
+}
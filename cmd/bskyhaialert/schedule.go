@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"text/template"
+	"time"
+
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+	"golang.org/x/xerrors"
+)
+
+// Schedule is a report posted on a schedule of its own besides the daily one, such as a weekly recap.
+type Schedule struct {
+	Name string `json:"name"`
+
+	// Weekday is the day of the week the report is posted on, such as "sunday", or every day if empty.
+	Weekday string `json:"weekday"`
+
+	// At is the time of the day the report is posted at, in POST_TIME_LAYOUT.
+	At string `json:"at"`
+
+	// Days is the number of days the differences in the report cover, 1 if not set.
+	Days int `json:"days"`
+
+	Template string `json:"template"`
+
+	// Metrics are the names of the metric providers collected for the report, or all of them if empty.
+	Metrics []string `json:"metrics"`
+}
+
+// ScheduleParam is the param of the report of a schedule, covering the days from From to To.
+type ScheduleParam struct {
+	*Param
+
+	Name string
+	From string
+	To   string
+}
+
+// validateSchedule checks the settings of the schedule other than its template.
+func validateSchedule(s Schedule) error {
+	if s.Name == "" {
+		return xerrors.New("name is required")
+	}
+
+	if s.Weekday != "" {
+		if _, err := parseWeekday(s.Weekday); err != nil {
+			return xerrors.Errorf("invalid weekday: %w", err)
+		}
+	}
+
+	if _, err := time.Parse(POST_TIME_LAYOUT, s.At); err != nil {
+		return xerrors.Errorf("at must be in HH:MM: %w", err)
+	}
+
+	if s.Days < 0 {
+		return xerrors.Errorf("days must not be negative, got %d", s.Days)
+	}
+
+	if _, err := selectMetricProviders(s.Metrics); err != nil {
+		return xerrors.Errorf("invalid metrics: %w", err)
+	}
+
+	return nil
+}
+
+// parseScheduleTemplates parses the templates of the schedules, in the same order.
+func parseScheduleTemplates(schedules []Schedule, funcMap template.FuncMap) ([]*template.Template, error) {
+	tmpls := make([]*template.Template, len(schedules))
+
+	for i, s := range schedules {
+		tmpl, err := template.New("schedule_" + s.Name).Funcs(funcMap).Parse(s.Template)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse template for %s: %w", s.Name, err)
+		}
+
+		tmpls[i] = tmpl
+	}
+
+	return tmpls, nil
+}
+
+// runSchedule posts the report of the schedule, comparing the current stats with the latest ones recorded
+// before the days it covers. Unlike the daily job, nothing is recorded.
+func (a *App) runSchedule(ctx context.Context, s Schedule, tmpl *template.Template, now time.Time) error {
+	days := s.Days
+	if days == 0 {
+		days = 1
+	}
+
+	from := now.AddDate(0, 0, -days)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	previous, err := a.store.GetLatestStatsBefore(from.AddDate(0, 0, 1))
+	if err != nil {
+		return xerrors.Errorf("failed to load previous stats: %w", err)
+	}
+
+	data := previous.Data
+
+	var newData Data
+	err = a.withSession(ctx, func() error {
+		newData, err = bskystats.FetchData(ctx, a.client)
+		return err
+	})
+	if err != nil {
+		return xerrors.Errorf("failed to fetch data: %w", err)
+	}
+
+	providers, err := selectMetricProviders(s.Metrics)
+	if err != nil {
+		return xerrors.Errorf("failed to select metrics: %w", err)
+	}
+
+	param := newParam(from, data, newData, a.cfg.RatioPrecision)
+	param.Metrics = collectMetrics(ctx, providers, &MetricEnv{
+		Client:  a.client,
+		Store:   a.store,
+		Config:  a.cfg,
+		Now:     now,
+		Today:   today,
+		Data:    data,
+		NewData: newData,
+		Param:   param,
+	})
+
+	return a.poster.postTemplate(ctx, tmpl, &ScheduleParam{
+		Param: param,
+		Name:  s.Name,
+		From:  from.Format(DATE_FORMAT),
+		To:    now.AddDate(0, 0, -1).Format(DATE_FORMAT),
+	})
+}
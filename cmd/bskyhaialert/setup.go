@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+	"golang.org/x/xerrors"
+)
+
+const (
+	DEFAULT_HOST = "https://bsky.social"
+	TEST_POST    = "bskyhaialertのテスト投稿です"
+)
+
+// setupConfig is the config written by the setup command, leaving everything else to the defaults.
+type setupConfig struct {
+	Host     string `json:"host"`
+	Handle   string `json:"handle"`
+	Password string `json:"password"`
+}
+
+// setupCommand asks for the account, checks that it can log in and writes the config readable only by the owner.
+func setupCommand(args []string) error {
+	fs, path := newFlagSet("setup")
+	fs.Parse(args)
+
+	if err := prepareDataDir(); err != nil {
+		return xerrors.Errorf("failed to prepare data dir: %w", err)
+	}
+
+	ctx := context.Background()
+	in := bufio.NewReader(os.Stdin)
+
+	if existsFile(*path) {
+		ok, err := confirm(in, fmt.Sprintf("%s already exists. Overwrite it?", *path))
+		if err != nil {
+			return xerrors.Errorf("failed to read answer: %w", err)
+		}
+
+		if !ok {
+			return nil
+		}
+	}
+
+	cfg := &setupConfig{}
+
+	var err error
+	if cfg.Host, err = prompt(in, fmt.Sprintf("Host [%s]: ", DEFAULT_HOST)); err != nil {
+		return xerrors.Errorf("failed to read host: %w", err)
+	}
+
+	if cfg.Host == "" {
+		cfg.Host = DEFAULT_HOST
+	}
+
+	if cfg.Handle, err = prompt(in, "Handle: "); err != nil {
+		return xerrors.Errorf("failed to read handle: %w", err)
+	}
+
+	cfg.Handle = strings.TrimPrefix(cfg.Handle, "@")
+
+	fmt.Print("App password: ")
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return xerrors.Errorf("failed to read password: %w", err)
+	}
+
+	cfg.Password = string(password)
+
+	client, err := newPasswordClient(ctx, cfg.Host, cfg.Handle, cfg.Password, "")
+	if err != nil {
+		return xerrors.Errorf("failed to log in: %w", err)
+	}
+
+	fmt.Printf("logged in as %s (%s)\n", client.Auth.Handle, client.Auth.Did)
+
+	// The login is checked for real, but the config and the test post are only logged.
+	if dryRun {
+		enableDryRun(client)
+	}
+
+	b, err := json.MarshalIndent(cfg, "", "\t")
+	if err != nil {
+		return xerrors.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := writeFile(*path, append(b, '\n'), 0o600); err != nil {
+		return xerrors.Errorf("failed to write config: %w", err)
+	}
+
+	fmt.Printf("%s written\n", *path)
+
+	ok, err := confirm(in, "Send a test post?")
+	if err != nil {
+		return xerrors.Errorf("failed to read answer: %w", err)
+	}
+
+	if !ok {
+		return nil
+	}
+
+	poster, err := newPoster(client, &Config{Langs: LANGS})
+	if err != nil {
+		return xerrors.Errorf("failed to create poster: %w", err)
+	}
+
+	out, err := poster.post(ctx, TEST_POST, nil, nil)
+	if err != nil {
+		return xerrors.Errorf("failed to post: %w", err)
+	}
+
+	fmt.Printf("posted %s\n", out.Uri)
+
+	return nil
+}
+
+// prompt prints the question and returns the line answered without surrounding spaces.
+func prompt(in *bufio.Reader, question string) (string, error) {
+	fmt.Print(question)
+
+	line, err := in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+
+	return strings.TrimSpace(line), nil
+}
+
+// confirm asks the yes/no question, defaulting to no.
+func confirm(in *bufio.Reader, question string) (bool, error) {
+	answer, err := prompt(in, question+" [y/N]: ")
+	if err != nil {
+		return false, err
+	}
+
+	return strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes"), nil
+}
+
+func existsFile(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
@@ -0,0 +1,420 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"text/template"
+	"time"
+
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+	"golang.org/x/xerrors"
+)
+
+const (
+	SINK_RETRIES        = 2
+	SINK_RETRY_INTERVAL = 30 * time.Second
+)
+
+// Report is the daily report handed to the sinks.
+type Report struct {
+	Now   time.Time
+	Today time.Time
+	Data  Data
+	Param *Param
+
+	// Template renders the text of the report, unless the sink has a template of its own.
+	Template *template.Template
+	TopPosts []*TopPost
+
+	// Formatter is the formatter selected for the sink, if any.
+	Formatter Formatter
+
+	// Force delivers the report even to the sinks it was already delivered to on the day.
+	Force bool
+}
+
+// Text renders the report with the formatter selected for the sink, or with its template.
+func (r *Report) Text() (string, error) {
+	return r.TextWith(r.Template)
+}
+
+// TextWith renders the report with the formatter selected for the sink, or with tmpl.
+func (r *Report) TextWith(tmpl *template.Template) (string, error) {
+	if r.Formatter != nil {
+		return r.Formatter.Format(r)
+	}
+
+	return bskystats.RenderTemplate(tmpl, r.Param)
+}
+
+// Sink is a destination the daily report is delivered to.
+type Sink interface {
+	Name() string
+	Deliver(ctx context.Context, report *Report) error
+}
+
+// deliverReport delivers the report to each of the sinks, formatted with the formatter selected for the sink if any,
+// retrying a sink that fails up to retries times.
+// A sink that keeps failing is logged and, if ntfy is not nil, alerted, without affecting the others.
+// The sinks the report was already delivered to on the day are skipped unless forced, and the others are recorded on success.
+// It reports whether the report has reached all of the sinks.
+func deliverReport(ctx context.Context, store *Store, sinks []Sink, formatters map[string]Formatter, report *Report, retries int, ntfy *Ntfy) bool {
+	delivered := true
+	for _, sink := range sinks {
+		var done bool
+		var err error
+		if !report.Force {
+			done, err = store.IsDailyDelivered(report.Today, sink.Name())
+		}
+
+		if err != nil {
+			slog.Error("failed to check delivery", "sink", sink.Name(), "error", err)
+		} else if done {
+			slog.Info("already delivered, skipping", "sink", sink.Name())
+			continue
+		}
+
+		report := *report
+		report.Formatter = formatters[sink.Name()]
+
+		ctx, span := tracer.Start(ctx, "deliver "+sink.Name())
+
+		err = sink.Deliver(ctx, &report)
+		for attempt := 1; err != nil && attempt <= retries; attempt++ {
+			slog.Warn("failed to deliver, retrying", "sink", sink.Name(), "attempt", attempt, "retries", retries, "error", err)
+
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+			case <-time.After(SINK_RETRY_INTERVAL):
+				err = sink.Deliver(ctx, &report)
+			}
+		}
+
+		endSpan(span, err)
+
+		if err != nil {
+			slog.Error("failed to deliver", "sink", sink.Name(), "error", err)
+			if ntfy != nil {
+				ntfy.alert(ctx, fmt.Sprintf("%sへの配信に失敗しました", sink.Name()), err)
+			}
+
+			delivered = false
+			continue
+		}
+
+		slog.Info("delivery success", "sink", sink.Name())
+
+		if err := store.SaveDailyDelivery(report.Today, sink.Name()); err != nil {
+			slog.Error("failed to save delivery", "sink", sink.Name(), "error", err)
+		}
+	}
+
+	return delivered
+}
+
+// PostSink posts the report on Bluesky, followed by the replies enabled in the config.
+type PostSink struct {
+	client *xrpc.Client
+	poster *Poster
+	store  *Store
+	cfg    *Config
+
+	headlineTmpl       *template.Template
+	chartAltTmpl       *template.Template
+	topPostsTmpl       *template.Template
+	thankFollowersTmpl *template.Template
+
+	// pending is the daily thread left halfway by the failed delivery at pendingAt, which a retry resumes.
+	pending   *Thread
+	pendingAt time.Time
+}
+
+func (s *PostSink) Name() string { return "bluesky" }
+
+func (s *PostSink) Deliver(ctx context.Context, report *Report) error {
+	var altTmpl *template.Template
+	if s.cfg.Chart {
+		altTmpl = s.chartAltTmpl
+	}
+
+	var threadTmpl *template.Template
+	if s.cfg.Thread {
+		threadTmpl = s.headlineTmpl
+	}
+
+	thread := s.pending
+	if thread == nil || !s.pendingAt.Equal(report.Now) {
+		thread = new(Thread)
+
+		if s.cfg.Chain {
+			var err error
+			thread.Reply, err = s.store.GetLatestDailyPostBefore(report.Now)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return xerrors.Errorf("failed to load previous post: %w", err)
+			}
+		}
+	}
+
+	text, err := report.Text()
+	if err != nil {
+		return xerrors.Errorf("failed to render template: %w", err)
+	}
+
+	thread, err = postDaily(ctx, s.poster, s.store, text, threadTmpl, altTmpl, report.Now, report.Param, thread)
+	if err != nil {
+		s.pending, s.pendingAt = thread, report.Now
+		return xerrors.Errorf("failed to post: %w", err)
+	}
+
+	s.pending = nil
+
+	if s.cfg.TopPostsReply && len(report.TopPosts) > 0 {
+		reply, err := postTopPosts(ctx, s.poster, s.topPostsTmpl, thread.Reply, report.Param.Yesterday, report.TopPosts)
+		if err != nil {
+			slog.Error("failed to post top posts", "error", err)
+		} else {
+			thread.Reply = reply
+		}
+	}
+
+	if s.cfg.ThankFollowers {
+		reply, err := postFollowerThanks(ctx, s.client, s.poster, s.thankFollowersTmpl, thread.Reply, report.Today, s.cfg.ThankFollowersLimit)
+		if err != nil {
+			slog.Error("failed to thank followers", "error", err)
+		} else {
+			thread.Reply = reply
+		}
+	}
+
+	if err := s.store.SaveDailyPost(report.Now, thread.Reply); err != nil {
+		slog.Error("failed to save post", "error", err)
+	}
+
+	if s.cfg.Pin {
+		if err := s.poster.pin(ctx, thread.Head); err != nil {
+			slog.Error("failed to pin post", "error", err)
+		}
+	}
+
+	return nil
+}
+
+// DmSink sends the report as a direct message.
+type DmSink struct {
+	messenger *Messenger
+}
+
+func (s *DmSink) Name() string { return "dm" }
+
+func (s *DmSink) Deliver(ctx context.Context, report *Report) error {
+	text, err := report.Text()
+	if err != nil {
+		return xerrors.Errorf("failed to render template: %w", err)
+	}
+
+	return s.messenger.send(ctx, text)
+}
+
+type DiscordSink struct {
+	webhook string
+}
+
+func (s *DiscordSink) Name() string { return "discord" }
+
+func (s *DiscordSink) Deliver(ctx context.Context, report *Report) error {
+	return sendDiscord(ctx, s.webhook, report.Param)
+}
+
+type SlackSink struct {
+	webhook string
+}
+
+func (s *SlackSink) Name() string { return "slack" }
+
+func (s *SlackSink) Deliver(ctx context.Context, report *Report) error {
+	text, err := report.Text()
+	if err != nil {
+		return xerrors.Errorf("failed to render template: %w", err)
+	}
+
+	return sendSlack(ctx, s.webhook, text, report.Param)
+}
+
+type TelegramSink struct {
+	token  string
+	chatId string
+	// store is used to render the chart, which is left out if it is nil.
+	store *Store
+}
+
+func (s *TelegramSink) Name() string { return "telegram" }
+
+func (s *TelegramSink) Deliver(ctx context.Context, report *Report) error {
+	var chart []byte
+	if s.store != nil {
+		var err error
+		chart, err = renderHistoryChart(s.store, report.Now)
+		if err != nil {
+			slog.Error("failed to render chart", "error", err)
+		}
+	}
+
+	text, err := report.Text()
+	if err != nil {
+		return xerrors.Errorf("failed to render template: %w", err)
+	}
+
+	return sendTelegram(ctx, s.token, s.chatId, text, report.Param, chart)
+}
+
+type MailSink struct {
+	mailer *Mailer
+	store  *Store
+}
+
+func (s *MailSink) Name() string { return "mail" }
+
+func (s *MailSink) Deliver(ctx context.Context, report *Report) error {
+	var chart []byte
+	if s.mailer.html {
+		var err error
+		chart, err = renderHistoryChart(s.store, report.Now)
+		if err != nil {
+			slog.Error("failed to render chart", "error", err)
+		}
+	}
+
+	text, err := report.Text()
+	if err != nil {
+		return xerrors.Errorf("failed to render template: %w", err)
+	}
+
+	return s.mailer.send(fmt.Sprintf("%sの統計", report.Param.Yesterday), text, chart)
+}
+
+type NtfySink struct {
+	ntfy *Ntfy
+}
+
+func (s *NtfySink) Name() string { return "ntfy" }
+
+func (s *NtfySink) Deliver(ctx context.Context, report *Report) error {
+	text, err := report.Text()
+	if err != nil {
+		return xerrors.Errorf("failed to render template: %w", err)
+	}
+
+	return s.ntfy.publish(ctx, fmt.Sprintf("%sの統計", report.Param.Yesterday), text, NTFY_PRIORITY_DEFAULT)
+}
+
+type MatrixSink struct {
+	homeserver string
+	token      string
+	roomId     string
+}
+
+func (s *MatrixSink) Name() string { return "matrix" }
+
+func (s *MatrixSink) Deliver(ctx context.Context, report *Report) error {
+	text, err := report.Text()
+	if err != nil {
+		return xerrors.Errorf("failed to render template: %w", err)
+	}
+
+	return sendMatrix(ctx, s.homeserver, s.token, s.roomId, text, report.Param)
+}
+
+type MastodonSink struct {
+	instance   string
+	token      string
+	visibility string
+	tmpl       *template.Template
+}
+
+func (s *MastodonSink) Name() string { return "mastodon" }
+
+func (s *MastodonSink) Deliver(ctx context.Context, report *Report) error {
+	text, err := report.TextWith(s.tmpl)
+	if err != nil {
+		return xerrors.Errorf("failed to render mastodon template: %w", err)
+	}
+
+	return sendMastodon(ctx, s.instance, s.token, s.visibility, text, report.Param)
+}
+
+type MisskeySink struct {
+	instance   string
+	token      string
+	visibility string
+}
+
+func (s *MisskeySink) Name() string { return "misskey" }
+
+func (s *MisskeySink) Deliver(ctx context.Context, report *Report) error {
+	text, err := report.Text()
+	if err != nil {
+		return xerrors.Errorf("failed to render template: %w", err)
+	}
+
+	return sendMisskey(ctx, s.instance, s.token, s.visibility, text)
+}
+
+type NostrSink struct {
+	relays     []string
+	privateKey string
+}
+
+func (s *NostrSink) Name() string { return "nostr" }
+
+func (s *NostrSink) Deliver(ctx context.Context, report *Report) error {
+	text, err := report.Text()
+	if err != nil {
+		return xerrors.Errorf("failed to render template: %w", err)
+	}
+
+	return sendNostr(ctx, s.relays, s.privateKey, text)
+}
+
+type XSink struct {
+	creds *XCredentials
+	tmpl  *template.Template
+}
+
+func (s *XSink) Name() string { return "x" }
+
+func (s *XSink) Deliver(ctx context.Context, report *Report) error {
+	text, err := report.TextWith(s.tmpl)
+	if err != nil {
+		return xerrors.Errorf("failed to render x template: %w", err)
+	}
+
+	return sendX(ctx, s.creds, text)
+}
+
+// AtomSink records the report and rewrites the Atom feed with it.
+type AtomSink struct {
+	store  *Store
+	path   string
+	url    string
+	handle string
+}
+
+func (s *AtomSink) Name() string { return "atom" }
+
+func (s *AtomSink) Deliver(ctx context.Context, report *Report) error {
+	text, err := report.Text()
+	if err != nil {
+		return xerrors.Errorf("failed to render template: %w", err)
+	}
+
+	if err := s.store.SaveDailyReport(report.Now.AddDate(0, 0, -1), text); err != nil {
+		return xerrors.Errorf("failed to save daily report: %w", err)
+	}
+
+	return writeAtomFeed(s.store, s.path, s.url, s.handle, report.Now)
+}
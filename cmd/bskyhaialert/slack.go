@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/xerrors"
+)
+
+type slackMessage struct {
+	// Text is the fallback shown in notifications.
+	Text   string        `json:"text"`
+	Blocks []*slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type string     `json:"type"`
+	Text *slackText `json:"text,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// sendSlack posts the rendered report to the Slack incoming webhook using Block Kit.
+func sendSlack(ctx context.Context, webhook, text string, param *Param) error {
+	title := fmt.Sprintf("%sの統計", param.Yesterday)
+
+	message := &slackMessage{
+		Text: title,
+		Blocks: []*slackBlock{
+			{Type: "header", Text: &slackText{Type: "plain_text", Text: title}},
+			{Type: "section", Text: &slackText{Type: "plain_text", Text: text}},
+		},
+	}
+
+	if err := postJSON(ctx, webhook, message); err != nil {
+		return xerrors.Errorf("failed to post to webhook: %w", err)
+	}
+
+	return nil
+}
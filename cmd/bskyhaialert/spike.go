@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/bluesky-social/indigo/xrpc"
+	"golang.org/x/xerrors"
+)
+
+const (
+	MENTION_SPIKE_MIN    = 5
+	MENTION_SPIKE_FORMAT = `⚠️ 直近1時間のメンションが{{ .Mentions }}件に急増しています(1時間平均{{ printf "%.1f" .Average }}件)`
+)
+
+type MentionSpikeParam struct {
+	Mentions int64
+	Average  float64
+}
+
+// detectMentionSpike returns the param of the alert if the mentions in the hour before now are at least min
+// and exceed multiple times the hourly average of the day before now, or nil otherwise.
+func detectMentionSpike(ctx context.Context, client *xrpc.Client, now time.Time, multiple float64, min int64) (*MentionSpikeParam, error) {
+	notifications, err := fetchNotificationsBetween(ctx, client, now.Add(-24*time.Hour), now, []string{"mention"})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to fetch mentions: %w", err)
+	}
+
+	param := &MentionSpikeParam{
+		Average: float64(len(notifications)) / 24,
+	}
+
+	hourAgo := now.Add(-time.Hour)
+	for _, notification := range notifications {
+		indexedAt, err := time.Parse(time.RFC3339, notification.IndexedAt)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse notification date: %w", err)
+		}
+
+		if !indexedAt.Before(hourAgo) {
+			param.Mentions++
+		}
+	}
+
+	if param.Mentions < min || float64(param.Mentions) <= param.Average*multiple {
+		return nil, nil
+	}
+
+	return param, nil
+}
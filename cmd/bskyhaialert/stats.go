@@ -0,0 +1,21 @@
+package main
+
+import "github.com/mopeneko/bskyhaialert/pkg/bskystats"
+
+// The types and constants of the bskystats package the bot is built around.
+type (
+	Data        = bskystats.Data
+	Store       = bskystats.Store
+	Snapshot    = bskystats.Snapshot
+	DailyReport = bskystats.DailyReport
+	Actor       = bskystats.Actor
+	PostCounts  = bskystats.PostCounts
+)
+
+const (
+	STATS_DB            = bskystats.STATS_DB
+	DATE_FORMAT         = bskystats.DATE_FORMAT
+	GRAPH_FOLLOWERS     = bskystats.GRAPH_FOLLOWERS
+	GRAPH_FOLLOWS       = bskystats.GRAPH_FOLLOWS
+	PROFILES_BATCH_SIZE = bskystats.PROFILES_BATCH_SIZE
+)
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// TELEGRAM_API is the URL of a method of the Bot API, which carries the bot token.
+// The errors of the requests to it are passed through redactURL so that the token is not logged.
+const TELEGRAM_API = "https://api.telegram.org/bot%s/%s"
+
+// telegramEscaper escapes the characters reserved by MarkdownV2.
+var telegramEscaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`",
+	">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+type telegramMessage struct {
+	ChatId    string `json:"chat_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode"`
+}
+
+// sendTelegram sends the rendered report to the Telegram chat, preceded by the chart if it is not nil.
+func sendTelegram(ctx context.Context, token, chatId, text string, param *Param, chart []byte) error {
+	if chart != nil {
+		if err := sendTelegramPhoto(ctx, token, chatId, chart); err != nil {
+			return xerrors.Errorf("failed to send chart: %w", err)
+		}
+	}
+
+	message := &telegramMessage{
+		ChatId:    chatId,
+		Text:      fmt.Sprintf("*%s*\n\n%s", telegramEscaper.Replace(param.Yesterday+"の統計"), telegramEscaper.Replace(text)),
+		ParseMode: "MarkdownV2",
+	}
+
+	if err := postJSON(ctx, fmt.Sprintf(TELEGRAM_API, token, "sendMessage"), message); err != nil {
+		return xerrors.Errorf("failed to send message: %w", redactURL(err))
+	}
+
+	return nil
+}
+
+func sendTelegramPhoto(ctx context.Context, token, chatId string, photo []byte) error {
+	body := new(bytes.Buffer)
+	w := multipart.NewWriter(body)
+
+	if err := w.WriteField("chat_id", chatId); err != nil {
+		return xerrors.Errorf("failed to write chat id: %w", err)
+	}
+
+	part, err := w.CreateFormFile("photo", "chart.png")
+	if err != nil {
+		return xerrors.Errorf("failed to create photo part: %w", err)
+	}
+
+	if _, err := part.Write(photo); err != nil {
+		return xerrors.Errorf("failed to write photo: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return xerrors.Errorf("failed to close multipart writer: %w", err)
+	}
+
+	if err := postBody(ctx, fmt.Sprintf(TELEGRAM_API, token, "sendPhoto"), w.FormDataContentType(), body.Bytes(), nil); err != nil {
+		return xerrors.Errorf("failed to post photo: %w", redactURL(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// failingTransport fails every request as a network error would.
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("connection reset by peer")
+}
+
+// withFailingHTTPClient makes the requests of the sinks fail until the end of the test.
+func withFailingHTTPClient(t *testing.T) {
+	t.Helper()
+
+	prev := httpClient
+	httpClient = &http.Client{Transport: failingTransport{}}
+	t.Cleanup(func() { httpClient = prev })
+}
+
+func TestSendTelegramRedactsToken(t *testing.T) {
+	withFailingHTTPClient(t)
+
+	const token = "123456:SECRET-bot-token"
+
+	tests := []struct {
+		name  string
+		chart []byte
+	}{
+		{"message", nil},
+		{"photo", []byte("png")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := sendTelegram(context.Background(), token, "42", "text", &Param{Yesterday: "2026-01-01"}, tt.chart)
+			if err == nil {
+				t.Fatal("sendTelegram() error = nil, want an error")
+			}
+
+			if strings.Contains(err.Error(), token) {
+				t.Errorf("sendTelegram() error = %q, which contains the token", err)
+			}
+
+			if !strings.Contains(err.Error(), "connection reset by peer") {
+				t.Errorf("sendTelegram() error = %q, want the cause of the failure", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+	"golang.org/x/xerrors"
+)
+
+// Templates are the templates of the posts, parsed from the config.
+type Templates struct {
+	funcMap template.FuncMap
+
+	// post is replaced when the template file is reloaded by the daily job, while a preview may be reading it.
+	post    atomic.Pointer[template.Template]
+	weekday map[time.Weekday]*template.Template
+
+	headline       *template.Template
+	topPosts       *template.Template
+	mentionSpike   *template.Template
+	leaderboard    *template.Template
+	thankFollowers *template.Template
+	weekly         *template.Template
+	monthly        *template.Template
+	yearly         *template.Template
+	milestone      *template.Template
+	chartAlt       *template.Template
+	mastodon       *template.Template
+	x              *template.Template
+	interval       *template.Template
+	duplicate      *template.Template
+
+	// schedules are the templates of the schedules of the config, in the same order.
+	schedules []*template.Template
+}
+
+// parseTemplates parses the templates of the config, or the default ones where it has none.
+func parseTemplates(cfg *Config) (*Templates, error) {
+	funcMap := template.FuncMap{
+		"formatDiff":      bskystats.FormatDiff,
+		"percent":         percent,
+		"comma":           comma,
+		"abs":             abs,
+		"humanize":        humanize,
+		"sign":            sign,
+		"fixed":           fixed,
+		"formatFloatDiff": formatFloatDiff,
+	}
+
+	t := &Templates{funcMap: funcMap}
+
+	// The errors are collected so that every broken template is reported at once.
+	var errs []error
+
+	post, err := loadPostTemplate(cfg.TemplatePath, funcMap)
+	if err != nil {
+		errs = append(errs, xerrors.Errorf("failed to load template: %w", err))
+	}
+
+	t.post.Store(post)
+
+	if t.weekday, err = parseWeekdayTemplates(cfg.WeekdayTemplates, funcMap); err != nil {
+		errs = append(errs, xerrors.Errorf("failed to parse weekday templates: %w", err))
+	}
+
+	if t.schedules, err = parseScheduleTemplates(cfg.Schedules, funcMap); err != nil {
+		errs = append(errs, xerrors.Errorf("failed to parse schedule templates: %w", err))
+	}
+
+	formats := []struct {
+		tmpl   **template.Template
+		name   string
+		format string
+	}{
+		{&t.headline, "headline", cfg.HeadlineTemplate},
+		{&t.topPosts, "top_posts", cfg.TopPostsTemplate},
+		{&t.mentionSpike, "mention_spike", cfg.MentionSpikeTemplate},
+		{&t.leaderboard, "leaderboard", cfg.LeaderboardTemplate},
+		{&t.thankFollowers, "thank_followers", cfg.ThankFollowersTemplate},
+		{&t.weekly, "weekly", cfg.WeeklyFormat},
+		{&t.monthly, "monthly", cfg.MonthlyTemplate},
+		{&t.yearly, "yearly", cfg.YearlyFormat},
+		{&t.milestone, "milestone", cfg.MilestoneTemplate},
+		{&t.chartAlt, "chart_alt", cfg.ChartAltTemplate},
+		{&t.mastodon, "mastodon", cfg.MastodonTemplate},
+		{&t.x, "x", cfg.XTemplate},
+		{&t.interval, "interval", cfg.IntervalTemplate},
+		{&t.duplicate, "duplicate_marker", cfg.DuplicateMarker},
+	}
+
+	for _, f := range formats {
+		if *f.tmpl, err = template.New(f.name).Funcs(funcMap).Parse(f.format); err != nil {
+			errs = append(errs, xerrors.Errorf("failed to parse %s template: %w", f.name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return t, nil
+}
+
+// postTemplate returns the template of the daily post on the weekday of now.
+func (t *Templates) postTemplate(now time.Time) *template.Template {
+	if tmpl, ok := t.weekday[now.Weekday()]; ok {
+		return tmpl
+	}
+
+	return t.post.Load()
+}
+
+// loadPostTemplate parses the daily post template from the file at path.
+// POST_FORMAT is used instead when path is empty.
+func loadPostTemplate(path string, funcMap template.FuncMap) (*template.Template, error) {
+	format := POST_FORMAT
+
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to read template file: %w", err)
+		}
+
+		format = string(b)
+	}
+
+	tmpl, err := template.New("post").Funcs(funcMap).Parse(format)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// parseWeekdayTemplates parses the daily post templates keyed by weekday names such as "sunday".
+func parseWeekdayTemplates(formats map[string]string, funcMap template.FuncMap) (map[time.Weekday]*template.Template, error) {
+	tmpls := make(map[time.Weekday]*template.Template, len(formats))
+
+	for name, format := range formats {
+		weekday, err := parseWeekday(name)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse weekday: %w", err)
+		}
+
+		tmpl, err := template.New("post_" + strings.ToLower(name)).Funcs(funcMap).Parse(format)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse template for %s: %w", name, err)
+		}
+
+		tmpls[weekday] = tmpl
+	}
+
+	return tmpls, nil
+}
+
+func parseWeekday(name string) (time.Weekday, error) {
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if strings.EqualFold(name, weekday.String()) {
+			return weekday, nil
+		}
+	}
+
+	return 0, xerrors.Errorf("unknown weekday: %s", name)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// MAX_POST_LENGTH is the maximum number of graphemes Bluesky accepts in a post.
+const MAX_POST_LENGTH = 300
+
+// splitText splits text into chunks of at most limit graphemes.
+// Chunks are broken at line boundaries where possible, and lines that are too long by themselves are broken between graphemes.
+func splitText(text string, limit int) []string {
+	if uniseg.GraphemeClusterCount(text) <= limit {
+		return []string{text}
+	}
+
+	var chunks []string
+	var chunk strings.Builder
+	length := 0
+
+	flush := func() {
+		if s := strings.TrimRight(chunk.String(), "\n"); s != "" {
+			chunks = append(chunks, s)
+		}
+
+		chunk.Reset()
+		length = 0
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		lineLength := uniseg.GraphemeClusterCount(strings.TrimRight(line, "\n"))
+
+		if length > 0 && length+lineLength > limit {
+			flush()
+		}
+
+		if lineLength <= limit {
+			chunk.WriteString(line)
+			length += uniseg.GraphemeClusterCount(line)
+			continue
+		}
+
+		graphemes := uniseg.NewGraphemes(line)
+		for graphemes.Next() {
+			if length == limit {
+				flush()
+			}
+
+			chunk.WriteString(graphemes.Str())
+			length++
+		}
+	}
+
+	flush()
+
+	return chunks
+}
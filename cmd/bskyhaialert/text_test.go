@@ -0,0 +1,29 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{"short", "hello", 10, []string{"hello"}},
+		{"exact", "hello", 5, []string{"hello"}},
+		{"lines", "aaa\nbbb\nccc", 7, []string{"aaa\nbbb", "ccc"}},
+		{"long line", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"graphemes", "👍🏽👍🏽👍🏽", 2, []string{"👍🏽👍🏽", "👍🏽"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitText(tt.text, tt.limit); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitText(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
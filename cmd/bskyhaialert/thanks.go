@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+	"golang.org/x/xerrors"
+)
+
+const (
+	THANK_FOLLOWERS_LIMIT  = 10
+	THANK_FOLLOWERS_FORMAT = `新しくフォローしてくださった皆さん、ありがとうございます！
+{{ range .Handles }}@{{ . }}
+{{ end }}{{ if .Others }}ほか{{ .Others }}人{{ end }}`
+)
+
+type ThankFollowersParam struct {
+	Handles []string
+	Others  int
+}
+
+// postFollowerThanks replies with the handles of up to limit accounts that followed the account on the day before today.
+// It returns a reference for replying to the last post, which is reply itself if nobody followed.
+func postFollowerThanks(ctx context.Context, client *xrpc.Client, poster *Poster, tmpl *template.Template, reply *bsky.FeedPost_ReplyRef, today time.Time, limit int) (*bsky.FeedPost_ReplyRef, error) {
+	notifications, err := fetchNotificationsBetween(ctx, client, today.AddDate(0, 0, -1), today, []string{"follow"})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to fetch follows: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	param := new(ThankFollowersParam)
+	for _, notification := range notifications {
+		if seen[notification.Author.Did] {
+			continue
+		}
+
+		seen[notification.Author.Did] = true
+
+		if len(param.Handles) < limit {
+			param.Handles = append(param.Handles, notification.Author.Handle)
+		} else {
+			param.Others++
+		}
+	}
+
+	if len(param.Handles) == 0 {
+		return reply, nil
+	}
+
+	text, err := bskystats.RenderTemplate(tmpl, param)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to render template: %w", err)
+	}
+
+	thread, err := poster.postThread(ctx, []string{strings.TrimSpace(text)}, nil, reply)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to post: %w", err)
+	}
+
+	return thread.Reply, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/xerrors"
+)
+
+// TRACING_SERVICE_NAME is the name of the service the traces are exported as.
+const TRACING_SERVICE_NAME = "bskyhaialert"
+
+// tracer traces the runs of the jobs. It does nothing unless setupTracing has enabled the export.
+var tracer = otel.Tracer("github.com/mopeneko/bskyhaialert")
+
+// setupTracing exports the traces to the OTLP/HTTP endpoint of the config, if any, and returns the function
+// that flushes the remaining ones. The OTEL_EXPORTER_OTLP_* environment variables are honored as well.
+func setupTracing(ctx context.Context, cfg *Config) (func(context.Context) error, error) {
+	if cfg.OtlpEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.OtlpEndpoint)}
+	if cfg.OtlpInsecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", TRACING_SERVICE_NAME))),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// endSpan ends the span, marking it as failed with err if it is not nil.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"golang.org/x/xerrors"
+)
+
+// VALIDATE_TIMEOUT bounds the requests made to check that the services are reachable.
+const VALIDATE_TIMEOUT = 10 * time.Second
+
+// validateConfig checks the config without logging in and returns all the problems found in it.
+func validateConfig(ctx context.Context, cfg *Config) []error {
+	var errs []error
+	problem := func(format string, args ...any) {
+		errs = append(errs, xerrors.Errorf(format, args...))
+	}
+
+	required := []struct {
+		key   string
+		value string
+	}{
+		{"host", cfg.Host},
+		{"handle", cfg.Handle},
+	}
+
+	for _, r := range required {
+		if r.value == "" {
+			problem("%s is required", r.key)
+		}
+	}
+
+	switch cfg.AuthMethod {
+	case AUTH_PASSWORD:
+		if cfg.Password == "" {
+			problem("password is required")
+		}
+	case AUTH_OAUTH:
+	default:
+		problem("auth_method must be %q or %q, got %q", AUTH_PASSWORD, AUTH_OAUTH, cfg.AuthMethod)
+	}
+
+	if err := setSessionStore(cfg); err != nil {
+		problem("session_store must be %q or %q, got %q", SESSION_STORE_FILE, SESSION_STORE_KEYRING, cfg.SessionStore)
+	}
+
+	if cfg.Handle != "" {
+		if _, err := syntax.ParseHandle(strings.TrimPrefix(cfg.Handle, "@")); err != nil {
+			problem("handle is not a valid handle: %w", err)
+		}
+	}
+
+	if cfg.ReporterHandle != "" {
+		if _, err := syntax.ParseHandle(strings.TrimPrefix(cfg.ReporterHandle, "@")); err != nil {
+			problem("reporter_handle is not a valid handle: %w", err)
+		}
+
+		if cfg.ReporterPassword == "" && cfg.AuthMethod != AUTH_OAUTH {
+			problem("reporter_password is required with reporter_handle")
+		}
+	}
+
+	for _, actor := range cfg.Watchlist {
+		if _, err := syntax.ParseAtIdentifier(strings.TrimPrefix(actor, "@")); err != nil {
+			problem("watchlist entry %q is not a valid handle or DID: %w", actor, err)
+		}
+	}
+
+	switch cfg.Delivery {
+	case DELIVERY_POST, DELIVERY_NONE:
+	case DELIVERY_DM:
+		if cfg.DmRecipient == "" {
+			problem("dm_recipient is required for dm delivery")
+		} else if _, err := syntax.ParseAtIdentifier(strings.TrimPrefix(cfg.DmRecipient, "@")); err != nil {
+			problem("dm_recipient is not a valid handle or DID: %w", err)
+		}
+	default:
+		problem("unknown delivery: %s", cfg.Delivery)
+	}
+
+	if cfg.MentionSpikeDm && cfg.Delivery != DELIVERY_DM {
+		if cfg.DmRecipient == "" {
+			problem("dm_recipient is required for mention_spike_dm")
+		} else if _, err := syntax.ParseAtIdentifier(strings.TrimPrefix(cfg.DmRecipient, "@")); err != nil {
+			problem("dm_recipient is not a valid handle or DID: %w", err)
+		}
+	}
+
+	if _, err := time.Parse(POST_TIME_LAYOUT, cfg.PostTime); err != nil {
+		problem("post_time must be in HH:MM: %w", err)
+	}
+
+	if _, err := newLogHandler(cfg, io.Discard); err != nil {
+		problem("invalid logging settings: %w", err)
+	}
+
+	if cfg.LogFile != "" && cfg.LogMaxSize <= 0 {
+		problem("log_max_size must be positive, got %d", cfg.LogMaxSize)
+	}
+
+	if cfg.LogMaxAge < 0 || cfg.LogMaxBackups < 0 {
+		problem("log_max_age and log_max_backups must not be negative")
+	}
+
+	if cfg.IntervalHours < 0 {
+		problem("interval_hours must not be negative, got %d", cfg.IntervalHours)
+	}
+
+	for i, s := range cfg.Schedules {
+		if err := validateSchedule(s); err != nil {
+			problem("schedules[%d] is invalid: %w", i, err)
+		}
+	}
+
+	if len(cfg.NostrRelays) > 0 {
+		if _, err := parseNostrPrivateKey(cfg.NostrPrivateKey); err != nil {
+			problem("nostr_private_key must be hex or nsec: %w", err)
+		}
+	}
+
+	if _, err := parseThreadgate(cfg.Threadgate); err != nil {
+		problem("invalid threadgate: %w", err)
+	}
+
+	if cfg.SmtpHost != "" {
+		if _, err := newMailer(cfg); err != nil {
+			problem("invalid smtp settings: %w", err)
+		}
+	}
+
+	if _, err := parseTemplates(cfg); err != nil {
+		errs = append(errs, unjoin(err)...)
+	}
+
+	if _, err := parseFormatters(cfg.Formats); err != nil {
+		problem("invalid formats: %w", err)
+	}
+
+	if cfg.Host != "" {
+		if err := checkReachable(ctx, strings.TrimSuffix(cfg.Host, "/")+"/xrpc/_health"); err != nil {
+			problem("host %s is not reachable: %w", cfg.Host, err)
+		}
+	}
+
+	return errs
+}
+
+// checkReachable reports whether the URL responds at all, regardless of its status.
+func checkReachable(ctx context.Context, url string) error {
+	ctx, cancel := context.WithTimeout(ctx, VALIDATE_TIMEOUT)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return xerrors.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return xerrors.Errorf("failed to send request: %w", err)
+	}
+
+	resp.Body.Close()
+
+	return nil
+}
+
+// unjoin returns the errors joined into err, or err alone.
+func unjoin(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+
+	return []error{err}
+}
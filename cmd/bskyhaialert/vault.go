@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	VAULT_AUTH_TOKEN      = "token"
+	VAULT_AUTH_APPROLE    = "approle"
+	VAULT_AUTH_KUBERNETES = "kubernetes"
+
+	// VAULT_TOKEN_ENV is read for the token when vault_token is empty, as the Vault CLI does.
+	VAULT_TOKEN_ENV = "VAULT_TOKEN"
+
+	// VAULT_KUBERNETES_TOKEN_PATH is the service account token sent to log in with the kubernetes auth method.
+	VAULT_KUBERNETES_TOKEN_PATH = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+	VAULT_TIMEOUT = 10 * time.Second
+)
+
+// Vault reads secrets from a HashiCorp Vault server.
+type Vault struct {
+	addr   string
+	token  string
+	client *http.Client
+}
+
+// newVault logs in to the Vault server of the config with its auth method.
+func newVault(ctx context.Context, cfg *Config) (*Vault, error) {
+	v := &Vault{
+		addr:   strings.TrimSuffix(cfg.VaultAddr, "/"),
+		client: &http.Client{Timeout: VAULT_TIMEOUT},
+	}
+
+	switch cfg.VaultAuth {
+	case VAULT_AUTH_TOKEN:
+		v.token = cfg.VaultToken
+		if v.token == "" {
+			v.token = os.Getenv(VAULT_TOKEN_ENV)
+		}
+	case VAULT_AUTH_APPROLE:
+		body := map[string]string{"role_id": cfg.VaultRoleId, "secret_id": cfg.VaultSecretId}
+		if err := v.login(ctx, "approle", body); err != nil {
+			return nil, xerrors.Errorf("failed to log in with approle: %w", err)
+		}
+	case VAULT_AUTH_KUBERNETES:
+		jwt, err := os.ReadFile(VAULT_KUBERNETES_TOKEN_PATH)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to read service account token: %w", err)
+		}
+
+		body := map[string]string{"role": cfg.VaultRole, "jwt": strings.TrimSpace(string(jwt))}
+		if err := v.login(ctx, "kubernetes", body); err != nil {
+			return nil, xerrors.Errorf("failed to log in with kubernetes: %w", err)
+		}
+	default:
+		return nil, xerrors.Errorf("unknown vault auth method: %s", cfg.VaultAuth)
+	}
+
+	return v, nil
+}
+
+// login logs in with the auth method mounted at path, keeping the token.
+func (v *Vault) login(ctx context.Context, path string, body any) error {
+	var out struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+
+	if err := v.do(ctx, http.MethodPost, "auth/"+path+"/login", body, &out); err != nil {
+		return xerrors.Errorf("failed to log in: %w", err)
+	}
+
+	v.token = out.Auth.ClientToken
+
+	return nil
+}
+
+// read returns the key-value pairs of the secret at path.
+// Both the versions of the KV secrets engine are supported, with the full path such as secret/data/bskyhaialert for v2.
+func (v *Vault) read(ctx context.Context, path string) (map[string]any, error) {
+	var out struct {
+		Data map[string]any `json:"data"`
+	}
+
+	if err := v.do(ctx, http.MethodGet, strings.TrimPrefix(path, "/"), nil, &out); err != nil {
+		return nil, xerrors.Errorf("failed to read secret: %w", err)
+	}
+
+	// KV v2 nests the pairs in data along with metadata.
+	if data, ok := out.Data["data"].(map[string]any); ok {
+		if _, ok := out.Data["metadata"]; ok {
+			return data, nil
+		}
+	}
+
+	return out.Data, nil
+}
+
+func (v *Vault) do(ctx context.Context, method, path string, body, out any) error {
+	var b bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&b).Encode(body); err != nil {
+			return xerrors.Errorf("failed to marshal body: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, v.addr+"/v1/"+path, &b)
+	if err != nil {
+		return xerrors.Errorf("failed to create request: %w", err)
+	}
+
+	if v.token != "" {
+		req.Header.Set("X-Vault-Token", v.token)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return xerrors.Errorf("failed to send request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return xerrors.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return xerrors.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// loadVaultSecrets overrides the keys of the config with the ones in the secret at vault_path, if vault_addr is set.
+// The keys of the secret are the ones of the config, such as password.
+func loadVaultSecrets(ctx context.Context, cfg *Config) error {
+	if cfg.VaultAddr == "" {
+		return nil
+	}
+
+	v, err := newVault(ctx, cfg)
+	if err != nil {
+		return xerrors.Errorf("failed to create vault client: %w", err)
+	}
+
+	secrets, err := v.read(ctx, cfg.VaultPath)
+	if err != nil {
+		return xerrors.Errorf("failed to read secrets: %w", err)
+	}
+
+	return overrideConfig(ctx, cfg, "vault", secrets)
+}
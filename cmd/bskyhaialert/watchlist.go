@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+)
+
+type WatchParam struct {
+	Handle             string
+	PostsCount         int64
+	PostsCountDiff     int64
+	FollowersCount     int64
+	FollowersCountDiff int64
+}
+
+// updateWatchlist records the stats of the watched accounts as of now and returns them
+// along with the differences from their previous stats. Accounts that cannot be fetched are skipped.
+func updateWatchlist(ctx context.Context, client *xrpc.Client, store *Store, now time.Time, handles []string) []*WatchParam {
+	datas, err := bskystats.FetchActorsData(ctx, client, handles)
+	if err != nil {
+		slog.Error("failed to fetch stats of watchlist", "error", err)
+		return nil
+	}
+
+	var params []*WatchParam
+	for _, handle := range handles {
+		data, ok := datas[handle]
+		if !ok {
+			slog.Error("failed to fetch stats: profile not found", "actor", handle)
+			continue
+		}
+
+		prev, err := store.GetLatestWatchStatsBefore(handle, now)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("failed to load previous stats", "actor", handle, "error", err)
+			continue
+		}
+
+		if errors.Is(err, sql.ErrNoRows) {
+			prev = data
+		}
+
+		if err := store.SaveWatchStats(handle, now, data); err != nil {
+			slog.Error("failed to save stats", "actor", handle, "error", err)
+			continue
+		}
+
+		params = append(params, &WatchParam{
+			Handle:             handle,
+			PostsCount:         data.Posts,
+			PostsCountDiff:     data.Posts - prev.Posts,
+			FollowersCount:     data.Followers,
+			FollowersCountDiff: data.Followers - prev.Followers,
+		})
+	}
+
+	return params
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/xerrors"
+)
+
+// SIGNATURE_HEADER carries the HMAC-SHA256 of the body signed with the webhook secret.
+const SIGNATURE_HEADER = "X-Signature-256"
+
+// WebhookPayload is the body of the generic JSON webhook.
+type WebhookPayload struct {
+	Data  Data   `json:"data"`
+	Param *Param `json:"param"`
+}
+
+// postJSON posts v encoded as JSON to the webhook URL.
+func postJSON(ctx context.Context, url string, v any) error {
+	return postJSONWithHeader(ctx, url, v, nil)
+}
+
+// postJSONWithHeader posts v encoded as JSON to the URL with the additional header.
+func postJSONWithHeader(ctx context.Context, url string, v any, header map[string]string) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return xerrors.Errorf("failed to marshal body: %w", err)
+	}
+
+	if err := postBody(ctx, url, "application/json", b, header); err != nil {
+		return xerrors.Errorf("failed to post body: %w", err)
+	}
+
+	return nil
+}
+
+// sendWebhooks posts the stats of the run to each of the URLs.
+// If the secret is not empty, the body is signed with it in SIGNATURE_HEADER.
+// A URL that fails does not stop the others, and the errors of all the failed ones are returned joined.
+func sendWebhooks(ctx context.Context, urls []string, secret string, data Data, param *Param) error {
+	b, err := json.Marshal(&WebhookPayload{Data: data, Param: param})
+	if err != nil {
+		return xerrors.Errorf("failed to marshal payload: %w", err)
+	}
+
+	header := map[string]string{}
+	if secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(b)
+		header[SIGNATURE_HEADER] = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	// The URLs often carry a secret, so the failed ones are identified by their index and host only.
+	var errs []error
+	for i, u := range urls {
+		if err := postBody(ctx, u, "application/json", b, header); err != nil {
+			errs = append(errs, xerrors.Errorf("failed to post to webhook %d (%s): %w", i, hostOf(u), redactURL(err)))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func postBody(ctx context.Context, url, contentType string, body []byte, header map[string]string) error {
+	return sendBody(ctx, http.MethodPost, url, contentType, body, header)
+}
+
+// sendBody sends the body with the method to the URL, failing unless the response is successful.
+func sendBody(ctx context.Context, method, url, contentType string, body []byte, header map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return xerrors.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return xerrors.Errorf("failed to send request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return xerrors.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// redactURL returns err with the URL of the failed request left out, for the URLs carrying a secret such as a token.
+// The *url.Error of the request is replaced with its operation and its cause, which do not include the URL.
+func redactURL(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+
+	return xerrors.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+}
+
+// hostOf returns the host of the URL, which identifies it without the secrets of its path and query.
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "invalid URL"
+	}
+
+	return u.Host
+}
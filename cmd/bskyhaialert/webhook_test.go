@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSendWebhooksRedactsURL(t *testing.T) {
+	withFailingHTTPClient(t)
+
+	urls := []string{
+		"https://hooks.example.com/services/SECRET-ONE",
+		"https://other.example.com/hook?token=SECRET-TWO",
+	}
+
+	err := sendWebhooks(context.Background(), urls, "", Data{}, &Param{})
+	if err == nil {
+		t.Fatal("sendWebhooks() error = nil, want an error")
+	}
+
+	for _, secret := range []string{"SECRET-ONE", "SECRET-TWO"} {
+		if strings.Contains(err.Error(), secret) {
+			t.Errorf("sendWebhooks() error = %q, which contains %s", err, secret)
+		}
+	}
+
+	for _, want := range []string{"webhook 0 (hooks.example.com)", "webhook 1 (other.example.com)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("sendWebhooks() error = %q, want it to identify %s", err, want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"text/template"
+	"time"
+
+	"github.com/mopeneko/bskyhaialert/pkg/bskystats"
+	"golang.org/x/xerrors"
+)
+
+const WEEKLY_POST_FORMAT = `【{{ .From }}〜{{ .To }}の週間統計】
+ポスト数: {{ formatDiff .PostsCountDiff }}
+フォロー数: {{ formatDiff .FollowsCountDiff }}
+フォロワー数: {{ formatDiff .FollowersCountDiff }}
+ベスト: {{ .BestDay }}({{ formatDiff .BestDayDiff }})
+ワースト: {{ .WorstDay }}({{ formatDiff .WorstDayDiff }})`
+
+type WeeklyParam struct {
+	From               string
+	To                 string
+	PostsCountDiff     int64
+	FollowsCountDiff   int64
+	FollowersCountDiff int64
+	BestDay            string
+	BestDayDiff        int64
+	WorstDay           string
+	WorstDayDiff       int64
+}
+
+// postWeeklySummary posts a summary of the 7 days before now.
+// The snapshot of now must already be saved.
+func postWeeklySummary(ctx context.Context, poster *Poster, store *Store, tmpl *template.Template, now time.Time) error {
+	param, err := newWeeklyParam(store, now)
+	if err != nil {
+		return xerrors.Errorf("failed to summarize week: %w", err)
+	}
+
+	return poster.postTemplate(ctx, tmpl, param)
+}
+
+// mailWeeklySummary mails a summary of the 7 days before now.
+// The snapshot of now must already be saved.
+func mailWeeklySummary(mailer *Mailer, store *Store, tmpl *template.Template, now time.Time) error {
+	param, err := newWeeklyParam(store, now)
+	if err != nil {
+		return xerrors.Errorf("failed to summarize week: %w", err)
+	}
+
+	text, err := bskystats.RenderTemplate(tmpl, param)
+	if err != nil {
+		return xerrors.Errorf("failed to render template: %w", err)
+	}
+
+	var chart []byte
+	if mailer.html {
+		chart, err = renderHistoryChart(store, now)
+		if err != nil {
+			slog.Error("failed to render chart", "error", err)
+		}
+	}
+
+	if err := mailer.send(fmt.Sprintf("%s〜%sの週間統計", param.From, param.To), text, chart); err != nil {
+		return xerrors.Errorf("failed to send mail: %w", err)
+	}
+
+	return nil
+}
+
+// newWeeklyParam summarizes the 7 days before now.
+func newWeeklyParam(store *Store, now time.Time) (*WeeklyParam, error) {
+	history, err := store.GetStatsBetween(now.AddDate(0, 0, -7), now)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get history: %w", err)
+	}
+
+	if len(history) < 2 {
+		return nil, xerrors.New("not enough history")
+	}
+
+	first, last := history[0], history[len(history)-1]
+
+	param := &WeeklyParam{
+		From:               now.AddDate(0, 0, -7).Format(DATE_FORMAT),
+		To:                 now.AddDate(0, 0, -1).Format(DATE_FORMAT),
+		PostsCountDiff:     last.Posts - first.Posts,
+		FollowsCountDiff:   last.Follows - first.Follows,
+		FollowersCountDiff: last.Followers - first.Followers,
+	}
+
+	for i := 1; i < len(history); i++ {
+		day, diff := history[i-1].Date, history[i].Followers-history[i-1].Followers
+
+		if param.BestDay == "" || diff > param.BestDayDiff {
+			param.BestDay, param.BestDayDiff = day, diff
+		}
+
+		if param.WorstDay == "" || diff < param.WorstDayDiff {
+			param.WorstDay, param.WorstDayDiff = day, diff
+		}
+	}
+
+	return param, nil
+}
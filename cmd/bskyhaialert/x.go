@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	X_TWEETS_URL = "https://api.twitter.com/2/tweets"
+	// X_POST_FORMAT is kept short because X counts each Japanese character twice against its 280 characters.
+	X_POST_FORMAT = `【{{ .Yesterday }}のBluesky統計】
+ポスト数: {{ .PostsCount }}({{ formatDiff .PostsCountDiff }})
+フォロー数: {{ .FollowsCount }}({{ formatDiff .FollowsCountDiff }})
+フォロワー数: {{ .FollowersCount }}({{ formatDiff .FollowersCountDiff }})`
+)
+
+// XCredentials are the OAuth 1.0a credentials of the app and the account posting to X.
+type XCredentials struct {
+	ConsumerKey       string
+	ConsumerSecret    string
+	AccessToken       string
+	AccessTokenSecret string
+}
+
+type xTweet struct {
+	Text string `json:"text"`
+}
+
+// sendX posts the rendered report to X through the API v2.
+func sendX(ctx context.Context, creds *XCredentials, text string) error {
+	b, err := json.Marshal(&xTweet{Text: text})
+	if err != nil {
+		return xerrors.Errorf("failed to marshal tweet: %w", err)
+	}
+
+	auth, err := creds.authorization("POST", X_TWEETS_URL, time.Now())
+	if err != nil {
+		return xerrors.Errorf("failed to sign request: %w", err)
+	}
+
+	header := map[string]string{"Authorization": auth}
+
+	if err := postBody(ctx, X_TWEETS_URL, "application/json", b, header); err != nil {
+		return xerrors.Errorf("failed to post tweet: %w", err)
+	}
+
+	return nil
+}
+
+// authorization returns the OAuth 1.0a Authorization header of the request signed with HMAC-SHA1.
+// The JSON body is not part of the signature.
+func (c *XCredentials) authorization(method, rawURL string, now time.Time) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", xerrors.Errorf("failed to generate nonce: %w", err)
+	}
+
+	params := map[string]string{
+		"oauth_consumer_key":     c.ConsumerKey,
+		"oauth_nonce":            hex.EncodeToString(nonce),
+		"oauth_signature_method": "HMAC-SHA1",
+		"oauth_timestamp":        strconv.FormatInt(now.Unix(), 10),
+		"oauth_token":            c.AccessToken,
+		"oauth_version":          "1.0",
+	}
+
+	keys := make([]string, 0, len(params)+1)
+	for k := range params {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, oauthEscape(k)+"="+oauthEscape(params[k]))
+	}
+
+	base := strings.Join([]string{method, oauthEscape(rawURL), oauthEscape(strings.Join(pairs, "&"))}, "&")
+
+	mac := hmac.New(sha1.New, []byte(oauthEscape(c.ConsumerSecret)+"&"+oauthEscape(c.AccessTokenSecret)))
+	mac.Write([]byte(base))
+	params["oauth_signature"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	keys = append(keys, "oauth_signature")
+	sort.Strings(keys)
+
+	fields := make([]string, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, fmt.Sprintf(`%s="%s"`, oauthEscape(k), oauthEscape(params[k])))
+	}
+
+	return "OAuth " + strings.Join(fields, ", "), nil
+}
+
+// oauthEscape percent-encodes s as per RFC 3986, which OAuth 1.0a requires.
+func oauthEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"text/template"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// YEAR_FORMAT is the layout of the years.
+const YEAR_FORMAT = "2006"
+
+const YEARLY_POST_FORMAT = `【{{ .Year }}年の振り返り】
+ポスト数: {{ .PostsCountDiff }}
+フォロワー数: {{ .FollowersCount }}({{ formatDiff .FollowersCountDiff }})
+最も増えた日: {{ .BestDay }}({{ formatDiff .BestDayDiff }})
+最長連続投稿: {{ .LongestStreak }}日`
+
+type YearlyParam struct {
+	Year               int
+	PostsCountDiff     int64
+	FollowersCount     int64
+	FollowersCountDiff int64
+	BestDay            string
+	BestDayDiff        int64
+	LongestStreak      int
+}
+
+// postYearInReview posts a review of the year before now.
+// The snapshot of now must already be saved.
+func postYearInReview(ctx context.Context, poster *Poster, store *Store, tmpl *template.Template, now time.Time) error {
+	from := time.Date(now.Year()-1, time.January, 1, 0, 0, 0, 0, now.Location())
+
+	history, err := store.GetStatsBetween(from, now)
+	if err != nil {
+		return xerrors.Errorf("failed to get history: %w", err)
+	}
+
+	if len(history) < 2 {
+		return xerrors.New("not enough history")
+	}
+
+	first, last := history[0], history[len(history)-1]
+
+	param := &YearlyParam{
+		Year:               from.Year(),
+		PostsCountDiff:     last.Posts - first.Posts,
+		FollowersCount:     last.Followers,
+		FollowersCountDiff: last.Followers - first.Followers,
+	}
+
+	streak := 0
+	for i := 1; i < len(history); i++ {
+		prev, cur := history[i-1], history[i]
+
+		if diff := cur.Followers - prev.Followers; param.BestDay == "" || diff > param.BestDayDiff {
+			param.BestDay, param.BestDayDiff = prev.Date, diff
+		}
+
+		consecutive, err := isNextDay(prev.Date, cur.Date)
+		if err != nil {
+			return xerrors.Errorf("failed to compare dates: %w", err)
+		}
+
+		if !consecutive || cur.Posts <= prev.Posts {
+			streak = 0
+			continue
+		}
+
+		streak++
+		if streak > param.LongestStreak {
+			param.LongestStreak = streak
+		}
+	}
+
+	return poster.postTemplate(ctx, tmpl, param)
+}
+
+// isNextDay reports whether the day b is the day after a.
+func isNextDay(a, b string) (bool, error) {
+	ta, err := time.Parse(DATE_FORMAT, a)
+	if err != nil {
+		return false, xerrors.Errorf("failed to parse date: %w", err)
+	}
+
+	tb, err := time.Parse(DATE_FORMAT, b)
+	if err != nil {
+		return false, xerrors.Errorf("failed to parse date: %w", err)
+	}
+
+	return ta.AddDate(0, 0, 1).Equal(tb), nil
+}
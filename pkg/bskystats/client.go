@@ -0,0 +1,172 @@
+package bskystats
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/xrpc"
+	"golang.org/x/xerrors"
+)
+
+// ErrAuthFactorTokenRequired is returned when the account has email 2FA enabled and no code was given.
+// The code is emailed to the account when this is returned.
+var ErrAuthFactorTokenRequired = errors.New("auth factor token required")
+
+// NewClient creates a client logged in to the host as the handle.
+// The session is persisted to Sessions and refreshed from it on the next call.
+func NewClient(ctx context.Context, host, handle, password string) (*xrpc.Client, error) {
+	return NewClientWithAuthFactorToken(ctx, host, handle, password, "")
+}
+
+// NewClientWithAuthFactorToken is NewClient sending the emailed 2FA code when a new session is created.
+func NewClientWithAuthFactorToken(ctx context.Context, host, handle, password, authFactorToken string) (*xrpc.Client, error) {
+	client := &xrpc.Client{
+		Client: new(http.Client),
+		Host:   host,
+		Auth:   &xrpc.AuthInfo{Handle: handle},
+	}
+
+	key := sessionKey(host, handle)
+
+	b, err := Sessions.Load(key)
+	if err != nil && !errors.Is(err, ErrSessionNotFound) {
+		return nil, xerrors.Errorf("failed to load session: %w", err)
+	}
+
+	if err == nil {
+		if err := json.Unmarshal(b, client.Auth); err != nil {
+			return nil, xerrors.Errorf("failed to parse session: %w", err)
+		}
+
+		if err := refreshSession(ctx, client); err == nil {
+			if err := saveSession(client.Auth, key); err != nil {
+				return nil, xerrors.Errorf("failed to save session: %w", err)
+			}
+
+			return client, nil
+		}
+	}
+
+	if err := createSession(ctx, client, password, authFactorToken); err != nil {
+		return nil, xerrors.Errorf("failed to create session: %w", err)
+	}
+
+	if err := saveSession(client.Auth, key); err != nil {
+		return nil, xerrors.Errorf("failed to save session: %w", err)
+	}
+
+	return client, nil
+}
+
+// RenewSession refreshes the session of the client created by NewClient, creating a new one with the password
+// when the refresh token is no longer accepted, and saves it to Sessions.
+func RenewSession(ctx context.Context, client *xrpc.Client, password, authFactorToken string) error {
+	if err := refreshSession(ctx, client); err != nil {
+		if err := createSession(ctx, client, password, authFactorToken); err != nil {
+			return xerrors.Errorf("failed to create session: %w", err)
+		}
+	}
+
+	if err := saveSession(client.Auth, sessionKey(client.Host, client.Auth.Handle)); err != nil {
+		return xerrors.Errorf("failed to save session: %w", err)
+	}
+
+	return nil
+}
+
+// SaveSession saves the session of the client created by NewClient to Sessions.
+func SaveSession(client *xrpc.Client) error {
+	return saveSession(client.Auth, sessionKey(client.Host, client.Auth.Handle))
+}
+
+// IsAuthError reports whether the request failed because the session expired or was revoked.
+func IsAuthError(err error) bool {
+	var xe *xrpc.Error
+	if !errors.As(err, &xe) {
+		return false
+	}
+
+	if xe.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+
+	var xre *xrpc.XRPCError
+	if !errors.As(err, &xre) {
+		return false
+	}
+
+	switch xre.ErrStr {
+	case "ExpiredToken", "InvalidToken", "AuthenticationRequired":
+		return true
+	}
+
+	return false
+}
+
+func sessionKey(host, handle string) string {
+	b := sha256.Sum256([]byte(fmt.Sprintf("%s_%s", host, handle)))
+	return fmt.Sprintf("auth_%s", hex.EncodeToString(b[:]))
+}
+
+// refreshSession exchanges the refresh token of the client for a new session.
+func refreshSession(ctx context.Context, client *xrpc.Client) error {
+	// The refresh token is sent in place of the access token.
+	rc := *client
+	rc.Auth = &xrpc.AuthInfo{AccessJwt: client.Auth.RefreshJwt}
+
+	session, err := atproto.ServerRefreshSession(ctx, &rc)
+	if err != nil {
+		return xerrors.Errorf("failed to refresh session: %w", err)
+	}
+
+	client.Auth.Did = session.Did
+	client.Auth.AccessJwt = session.AccessJwt
+	client.Auth.RefreshJwt = session.RefreshJwt
+
+	return nil
+}
+
+func createSession(ctx context.Context, client *xrpc.Client, password, authFactorToken string) error {
+	input := &atproto.ServerCreateSession_Input{
+		Identifier: client.Auth.Handle,
+		Password:   password,
+	}
+	if authFactorToken != "" {
+		input.AuthFactorToken = &authFactorToken
+	}
+
+	session, err := atproto.ServerCreateSession(ctx, client, input)
+	if err != nil {
+		var xe *xrpc.XRPCError
+		if errors.As(err, &xe) && xe.ErrStr == "AuthFactorTokenRequired" {
+			return xerrors.Errorf("failed to create session: %w", ErrAuthFactorTokenRequired)
+		}
+
+		return xerrors.Errorf("failed to create session: %w", err)
+	}
+
+	client.Auth.Did = session.Did
+	client.Auth.AccessJwt = session.AccessJwt
+	client.Auth.RefreshJwt = session.RefreshJwt
+
+	return nil
+}
+
+func saveSession(auth *xrpc.AuthInfo, key string) error {
+	b, err := json.Marshal(auth)
+	if err != nil {
+		return xerrors.Errorf("failed to marshal auth: %w", err)
+	}
+
+	if err := Sessions.Save(key, b); err != nil {
+		return xerrors.Errorf("failed to save auth: %w", err)
+	}
+
+	return nil
+}
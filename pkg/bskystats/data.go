@@ -0,0 +1,105 @@
+// Package bskystats fetches the stats of Bluesky accounts, keeps their history and renders reports of them.
+package bskystats
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/xrpc"
+	"golang.org/x/xerrors"
+)
+
+// PROFILES_BATCH_SIZE is the maximum number of profiles app.bsky.actor.getProfiles returns per call.
+const PROFILES_BATCH_SIZE = 25
+
+// Data is the stats of an account at a point in time.
+type Data struct {
+	Posts        int64 `json:"posts"`
+	Follows      int64 `json:"follows"`
+	Followers    int64 `json:"followers"`
+	Lists        int64 `json:"lists"`
+	Feedgens     int64 `json:"feedgens"`
+	StarterPacks int64 `json:"starter_packs"`
+	Labeler      bool  `json:"labeler"`
+}
+
+// FetchData fetches the stats of the account the client is logged in as.
+func FetchData(ctx context.Context, client *xrpc.Client) (Data, error) {
+	return FetchActorData(ctx, client, client.Auth.Handle)
+}
+
+// FetchActorData fetches the stats of the actor.
+func FetchActorData(ctx context.Context, client *xrpc.Client, actor string) (Data, error) {
+	profile, err := bsky.ActorGetProfile(ctx, client, actor)
+	if err != nil {
+		return Data{}, xerrors.Errorf("failed to get profile: %w", err)
+	}
+
+	return NewData(profile), nil
+}
+
+// FetchActorsData fetches the stats of the actors in batches of PROFILES_BATCH_SIZE.
+// The result is keyed by the actors as given. Actors whose profiles are not returned are absent from it.
+func FetchActorsData(ctx context.Context, client *xrpc.Client, actors []string) (map[string]Data, error) {
+	result := make(map[string]Data, len(actors))
+	for start := 0; start < len(actors); start += PROFILES_BATCH_SIZE {
+		batch := actors[start:min(start+PROFILES_BATCH_SIZE, len(actors))]
+
+		out, err := bsky.ActorGetProfiles(ctx, client, batch)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get profiles: %w", err)
+		}
+
+		profiles := make(map[string]*bsky.ActorDefs_ProfileViewDetailed, len(out.Profiles)*2)
+		for _, profile := range out.Profiles {
+			profiles[profile.Did] = profile
+			profiles[strings.ToLower(profile.Handle)] = profile
+		}
+
+		for _, actor := range batch {
+			if profile, ok := profiles[strings.ToLower(strings.TrimPrefix(actor, "@"))]; ok {
+				result[actor] = NewData(profile)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// NewData extracts the counters from the profile.
+func NewData(profile *bsky.ActorDefs_ProfileViewDetailed) Data {
+	var data Data
+
+	if profile.PostsCount != nil {
+		data.Posts = *profile.PostsCount
+	}
+
+	if profile.FollowsCount != nil {
+		data.Follows = *profile.FollowsCount
+	}
+
+	if profile.FollowersCount != nil {
+		data.Followers = *profile.FollowersCount
+	}
+
+	if associated := profile.Associated; associated != nil {
+		if associated.Lists != nil {
+			data.Lists = *associated.Lists
+		}
+
+		if associated.Feedgens != nil {
+			data.Feedgens = *associated.Feedgens
+		}
+
+		if associated.StarterPacks != nil {
+			data.StarterPacks = *associated.StarterPacks
+		}
+
+		if associated.Labeler != nil {
+			data.Labeler = *associated.Labeler
+		}
+	}
+
+	return data
+}
@@ -0,0 +1,59 @@
+package bskystats
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/xerrors"
+)
+
+// WriteFileAtomic writes the file like os.WriteFile, but through a temporary file renamed over it once synced,
+// so that a crash never leaves it truncated.
+func WriteFileAtomic(name string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(name)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return xerrors.Errorf("failed to create temporary file: %w", err)
+	}
+
+	// Removing fails harmlessly once the file is renamed.
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return xerrors.Errorf("failed to write temporary file: %w", err)
+	}
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return xerrors.Errorf("failed to chmod temporary file: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return xerrors.Errorf("failed to sync temporary file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return xerrors.Errorf("failed to close temporary file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return xerrors.Errorf("failed to rename temporary file: %w", err)
+	}
+
+	// The rename itself is only durable once the directory is synced.
+	d, err := os.Open(dir)
+	if err != nil {
+		return xerrors.Errorf("failed to open dir: %w", err)
+	}
+
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return xerrors.Errorf("failed to sync dir: %w", err)
+	}
+
+	return nil
+}
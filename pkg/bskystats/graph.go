@@ -0,0 +1,19 @@
+package bskystats
+
+// The kinds of the graph snapshots.
+const (
+	GRAPH_FOLLOWERS = "followers"
+	GRAPH_FOLLOWS   = "follows"
+)
+
+type Actor struct {
+	Did    string `json:"did"`
+	Handle string `json:"handle"`
+}
+
+// PostCounts is the breakdown of the posts made on a day.
+type PostCounts struct {
+	Originals int64
+	Replies   int64
+	Reposts   int64
+}
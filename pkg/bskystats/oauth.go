@@ -0,0 +1,170 @@
+package bskystats
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/bluesky-social/indigo/atproto/auth/oauth"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/xrpc"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// OAUTH_CALLBACK_URL is the redirect URI declared by the client.
+	// Loopback redirects may use any port, so the one of the login server is added on login.
+	OAUTH_CALLBACK_URL = "http://127.0.0.1/callback"
+)
+
+// OAUTH_SCOPES are the scopes asked for, covering the posts and the DMs.
+var OAUTH_SCOPES = []string{"atproto", "transition:generic", "transition:chat.bsky"}
+
+// OAuthLogin authorizes the handle with atproto OAuth and saves the session for NewOAuthClient.
+// authorize is called with the URL the user has to open in a browser.
+func OAuthLogin(ctx context.Context, handle string, authorize func(url string)) error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return xerrors.Errorf("failed to listen: %w", err)
+	}
+
+	defer listener.Close()
+
+	config := newOAuthConfig()
+	config.CallbackURL = fmt.Sprintf("http://%s/callback", listener.Addr())
+
+	app := oauth.NewClientApp(&config, newOAuthStore(handle))
+
+	url, err := app.StartAuthFlow(ctx, handle)
+	if err != nil {
+		return xerrors.Errorf("failed to start auth flow: %w", err)
+	}
+
+	errc := make(chan error, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /callback", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := app.ProcessCallback(r.Context(), r.URL.Query()); err != nil {
+			http.Error(w, "Login failed. Check the terminal for details.", http.StatusBadRequest)
+			errc <- xerrors.Errorf("failed to process callback: %w", err)
+			return
+		}
+
+		fmt.Fprintln(w, "Logged in. You can close this window.")
+		errc <- nil
+	})
+
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+
+	authorize(url)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// NewOAuthClient creates a client from the session saved by OAuthLogin.
+// The requests are signed with DPoP, and the tokens are refreshed and saved again when they expire.
+func NewOAuthClient(ctx context.Context, handle string) (*xrpc.Client, error) {
+	store := newOAuthStore(handle)
+
+	data, err := store.GetSession(ctx, "", "")
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get session: %w", err)
+	}
+
+	config := newOAuthConfig()
+	app := oauth.NewClientApp(&config, store)
+
+	session, err := app.ResumeSession(ctx, data.AccountDID, data.SessionID)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to resume session: %w", err)
+	}
+
+	return &xrpc.Client{
+		Client: &http.Client{Transport: &dpopTransport{session: session}},
+		Host:   data.HostURL,
+		Auth:   &xrpc.AuthInfo{Handle: handle, Did: data.AccountDID.String()},
+	}, nil
+}
+
+func newOAuthConfig() oauth.ClientConfig {
+	config := oauth.NewLocalhostConfig(OAUTH_CALLBACK_URL, OAUTH_SCOPES)
+	config.UserAgent = "bskyhaialert"
+
+	return config
+}
+
+// dpopTransport authorizes the XRPC requests with the OAuth session.
+type dpopTransport struct {
+	session *oauth.ClientSession
+}
+
+func (t *dpopTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	nsid, err := syntax.ParseNSID(strings.TrimPrefix(req.URL.Path, "/xrpc/"))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse nsid: %w", err)
+	}
+
+	return t.session.DoWithAuth(&http.Client{Transport: http.DefaultTransport}, req.Clone(req.Context()), nsid)
+}
+
+// oauthStore keeps the session of the handle in Sessions, and the pending auth requests in memory.
+type oauthStore struct {
+	*oauth.MemStore
+	key string
+}
+
+func newOAuthStore(handle string) *oauthStore {
+	b := sha256.Sum256([]byte(handle))
+
+	return &oauthStore{
+		MemStore: oauth.NewMemStore(),
+		key:      fmt.Sprintf("oauth_%s", hex.EncodeToString(b[:])),
+	}
+}
+
+func (s *oauthStore) GetSession(ctx context.Context, did syntax.DID, sessionID string) (*oauth.ClientSessionData, error) {
+	b, err := Sessions.Load(s.key)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load session: %w", err)
+	}
+
+	data := new(oauth.ClientSessionData)
+	if err := json.Unmarshal(b, data); err != nil {
+		return nil, xerrors.Errorf("failed to parse session: %w", err)
+	}
+
+	return data, nil
+}
+
+func (s *oauthStore) SaveSession(ctx context.Context, sess oauth.ClientSessionData) error {
+	b, err := json.Marshal(sess)
+	if err != nil {
+		return xerrors.Errorf("failed to marshal session: %w", err)
+	}
+
+	if err := Sessions.Save(s.key, b); err != nil {
+		return xerrors.Errorf("failed to save session: %w", err)
+	}
+
+	return nil
+}
+
+func (s *oauthStore) DeleteSession(ctx context.Context, did syntax.DID, sessionID string) error {
+	if err := Sessions.Delete(s.key); err != nil {
+		return xerrors.Errorf("failed to delete session: %w", err)
+	}
+
+	return nil
+}
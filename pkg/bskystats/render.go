@@ -0,0 +1,33 @@
+package bskystats
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"golang.org/x/xerrors"
+)
+
+// RenderTemplate executes the template with param and returns the text.
+func RenderTemplate(tmpl *template.Template, param any) (string, error) {
+	buf := new(bytes.Buffer)
+
+	if err := tmpl.Execute(buf, param); err != nil {
+		return "", xerrors.Errorf("failed to execute template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// FormatDiff formats diff with its sign, such as +3, -2 or ±0.
+func FormatDiff(diff int64) string {
+	if diff == 0 {
+		return "±0"
+	}
+
+	if diff > 0 {
+		return fmt.Sprintf("+%d", diff)
+	}
+
+	return fmt.Sprintf("%d", diff)
+}
@@ -0,0 +1,187 @@
+package bskystats
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// KEYRING_SERVICE is the service the sessions are stored under in the keyring.
+	KEYRING_SERVICE = "bskyhaialert"
+
+	SESSION_SALT_SIZE  = 16
+	SESSION_NONCE_SIZE = 24
+)
+
+// ErrSessionNotFound is returned by a SessionStore that has no session under the key.
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionStore persists the sessions of the clients by key.
+type SessionStore interface {
+	Load(key string) ([]byte, error)
+	Save(key string, b []byte) error
+	Delete(key string) error
+}
+
+// Sessions is where NewClient and NewOAuthClient persist the sessions.
+var Sessions SessionStore = FileSessionStore{}
+
+// FileSessionStore keeps each session in a JSON file named after the key in Dir, or the working directory if empty.
+type FileSessionStore struct {
+	Dir string
+}
+
+func (s FileSessionStore) path(key string) string {
+	return filepath.Join(s.Dir, key+".json")
+}
+
+func (s FileSessionStore) Load(key string) ([]byte, error) {
+	b, err := os.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read session file: %w", err)
+	}
+
+	return b, nil
+}
+
+func (s FileSessionStore) Save(key string, b []byte) error {
+	if err := WriteFileAtomic(s.path(key), b, 0o600); err != nil {
+		return xerrors.Errorf("failed to write session file: %w", err)
+	}
+
+	return nil
+}
+
+func (s FileSessionStore) Delete(key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		return xerrors.Errorf("failed to remove session file: %w", err)
+	}
+
+	return nil
+}
+
+// KeyringSessionStore keeps the sessions in the keyring of the OS, such as Keychain, Secret Service or Windows Credential Manager.
+type KeyringSessionStore struct{}
+
+func (KeyringSessionStore) Load(key string) ([]byte, error) {
+	s, err := keyring.Get(KEYRING_SERVICE, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get session from keyring: %w", err)
+	}
+
+	return []byte(s), nil
+}
+
+func (KeyringSessionStore) Save(key string, b []byte) error {
+	if err := keyring.Set(KEYRING_SERVICE, key, string(b)); err != nil {
+		return xerrors.Errorf("failed to set session to keyring: %w", err)
+	}
+
+	return nil
+}
+
+func (KeyringSessionStore) Delete(key string) error {
+	if err := keyring.Delete(KEYRING_SERVICE, key); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return xerrors.Errorf("failed to delete session from keyring: %w", err)
+	}
+
+	return nil
+}
+
+// EncryptedSessionStore encrypts the sessions with a key derived from the passphrase before saving them to Store.
+// The sessions are saved in base64 so that they fit in the keyring too.
+// The sessions saved in plain text before are still loaded, and encrypted on the next save.
+type EncryptedSessionStore struct {
+	Store      SessionStore
+	Passphrase string
+}
+
+func (s EncryptedSessionStore) Load(key string) ([]byte, error) {
+	b, err := s.Store.Load(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(b, []byte("{")) {
+		return b, nil
+	}
+
+	b, err = base64.StdEncoding.DecodeString(string(b))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode session: %w", err)
+	}
+
+	if len(b) < SESSION_SALT_SIZE+SESSION_NONCE_SIZE {
+		return nil, xerrors.New("encrypted session is too short")
+	}
+
+	salt, b := b[:SESSION_SALT_SIZE], b[SESSION_SALT_SIZE:]
+
+	var nonce [SESSION_NONCE_SIZE]byte
+	copy(nonce[:], b)
+
+	secret, err := s.deriveKey(salt)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to derive key: %w", err)
+	}
+
+	plain, ok := secretbox.Open(nil, b[SESSION_NONCE_SIZE:], &nonce, secret)
+	if !ok {
+		return nil, xerrors.New("failed to decrypt session, check the passphrase")
+	}
+
+	return plain, nil
+}
+
+func (s EncryptedSessionStore) Save(key string, b []byte) error {
+	salt := make([]byte, SESSION_SALT_SIZE)
+	if _, err := rand.Read(salt); err != nil {
+		return xerrors.Errorf("failed to generate salt: %w", err)
+	}
+
+	var nonce [SESSION_NONCE_SIZE]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return xerrors.Errorf("failed to generate nonce: %w", err)
+	}
+
+	secret, err := s.deriveKey(salt)
+	if err != nil {
+		return xerrors.Errorf("failed to derive key: %w", err)
+	}
+
+	out := append(salt, nonce[:]...)
+	out = secretbox.Seal(out, b, &nonce, secret)
+
+	return s.Store.Save(key, []byte(base64.StdEncoding.EncodeToString(out)))
+}
+
+func (s EncryptedSessionStore) Delete(key string) error {
+	return s.Store.Delete(key)
+}
+
+func (s EncryptedSessionStore) deriveKey(salt []byte) (*[32]byte, error) {
+	b, err := scrypt.Key([]byte(s.Passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	var key [32]byte
+	copy(key[:], b)
+
+	return &key, nil
+}
@@ -0,0 +1,660 @@
+package bskystats
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	_ "github.com/mattn/go-sqlite3"
+	"golang.org/x/xerrors"
+)
+
+const (
+	STATS_DB    = "stats.db"
+	DATE_FORMAT = "2006-01-02"
+	TIME_FORMAT = time.RFC3339
+	SCHEMA      = `
+CREATE TABLE IF NOT EXISTS stats (
+	date          TEXT PRIMARY KEY,
+	posts         INTEGER NOT NULL,
+	follows       INTEGER NOT NULL,
+	followers     INTEGER NOT NULL,
+	lists         INTEGER NOT NULL DEFAULT 0,
+	feedgens      INTEGER NOT NULL DEFAULT 0,
+	starter_packs INTEGER NOT NULL DEFAULT 0,
+	labeler       INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS milestones (
+	followers INTEGER PRIMARY KEY,
+	date      TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS daily_posts (
+	date       TEXT PRIMARY KEY,
+	root_uri   TEXT NOT NULL,
+	root_cid   TEXT NOT NULL,
+	parent_uri TEXT NOT NULL,
+	parent_cid TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS post_counts (
+	date      TEXT PRIMARY KEY,
+	originals INTEGER NOT NULL,
+	replies   INTEGER NOT NULL,
+	reposts   INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS watch_stats (
+	actor     TEXT NOT NULL,
+	date      TEXT NOT NULL,
+	posts     INTEGER NOT NULL,
+	follows   INTEGER NOT NULL,
+	followers INTEGER NOT NULL,
+	PRIMARY KEY (actor, date)
+);
+
+CREATE TABLE IF NOT EXISTS graph (
+	date   TEXT NOT NULL,
+	kind   TEXT NOT NULL,
+	did    TEXT NOT NULL,
+	handle TEXT NOT NULL,
+	PRIMARY KEY (date, kind, did)
+);
+
+CREATE TABLE IF NOT EXISTS notification_counts (
+	date   TEXT NOT NULL,
+	reason TEXT NOT NULL,
+	count  INTEGER NOT NULL,
+	PRIMARY KEY (date, reason)
+);
+
+CREATE TABLE IF NOT EXISTS daily_reports (
+	date TEXT PRIMARY KEY,
+	text TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS daily_runs (
+	date TEXT PRIMARY KEY
+);
+
+CREATE TABLE IF NOT EXISTS daily_deliveries (
+	date TEXT NOT NULL,
+	sink TEXT NOT NULL,
+	PRIMARY KEY (date, sink)
+);
+
+CREATE TABLE IF NOT EXISTS periodic_posts (
+	kind   TEXT NOT NULL,
+	period TEXT NOT NULL,
+	PRIMARY KEY (kind, period)
+);
+
+CREATE TABLE IF NOT EXISTS interval_stats (
+	time          TEXT PRIMARY KEY,
+	posts         INTEGER NOT NULL,
+	follows       INTEGER NOT NULL,
+	followers     INTEGER NOT NULL,
+	lists         INTEGER NOT NULL,
+	feedgens      INTEGER NOT NULL,
+	starter_packs INTEGER NOT NULL,
+	labeler       INTEGER NOT NULL
+);`
+)
+
+type Snapshot struct {
+	Date string
+	Data
+}
+
+// DailyReport is the rendered report of a day.
+type DailyReport struct {
+	Date string
+	Text string
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+// OpenStore opens the SQLite database at path, creating and migrating its tables as needed.
+func OpenStore(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to open database: %w", err)
+	}
+
+	if _, err := db.Exec(SCHEMA); err != nil {
+		db.Close()
+		return nil, xerrors.Errorf("failed to create tables: %w", err)
+	}
+
+	store := &Store{db: db}
+
+	if err := store.migrate(); err != nil {
+		db.Close()
+		return nil, xerrors.Errorf("failed to migrate database: %w", err)
+	}
+
+	return store, nil
+}
+
+// migrate adds the columns introduced after the tables were first created.
+func (s *Store) migrate() error {
+	columns := []struct {
+		table      string
+		name       string
+		definition string
+	}{
+		{"stats", "lists", "INTEGER NOT NULL DEFAULT 0"},
+		{"stats", "feedgens", "INTEGER NOT NULL DEFAULT 0"},
+		{"stats", "starter_packs", "INTEGER NOT NULL DEFAULT 0"},
+		{"stats", "labeler", "INTEGER NOT NULL DEFAULT 0"},
+	}
+
+	for _, column := range columns {
+		var count int
+
+		row := s.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, column.table, column.name)
+		if err := row.Scan(&count); err != nil {
+			return xerrors.Errorf("failed to get table info: %w", err)
+		}
+
+		if count > 0 {
+			continue
+		}
+
+		if _, err := s.db.Exec(`ALTER TABLE ` + column.table + ` ADD COLUMN ` + column.name + ` ` + column.definition); err != nil {
+			return xerrors.Errorf("failed to add column: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// SaveStats records data as the snapshot of the given day, replacing any existing row.
+func (s *Store) SaveStats(date time.Time, data Data) error {
+	if _, err := s.db.Exec(
+		`INSERT INTO stats (date, posts, follows, followers, lists, feedgens, starter_packs, labeler) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(date) DO UPDATE SET posts = excluded.posts, follows = excluded.follows, followers = excluded.followers,
+		lists = excluded.lists, feedgens = excluded.feedgens, starter_packs = excluded.starter_packs, labeler = excluded.labeler`,
+		date.Format(DATE_FORMAT), data.Posts, data.Follows, data.Followers, data.Lists, data.Feedgens, data.StarterPacks, data.Labeler,
+	); err != nil {
+		return xerrors.Errorf("failed to save stats: %w", err)
+	}
+
+	return nil
+}
+
+// InitStats records data as the snapshot of the given day unless one already exists.
+func (s *Store) InitStats(date time.Time, data Data) error {
+	if _, err := s.db.Exec(
+		`INSERT OR IGNORE INTO stats (date, posts, follows, followers, lists, feedgens, starter_packs, labeler) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		date.Format(DATE_FORMAT), data.Posts, data.Follows, data.Followers, data.Lists, data.Feedgens, data.StarterPacks, data.Labeler,
+	); err != nil {
+		return xerrors.Errorf("failed to init stats: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestStatsBefore returns the most recent snapshot recorded before the given day.
+// It returns an error wrapping sql.ErrNoRows if no such snapshot exists.
+func (s *Store) GetLatestStatsBefore(date time.Time) (Snapshot, error) {
+	var snapshot Snapshot
+
+	row := s.db.QueryRow(
+		`SELECT date, posts, follows, followers, lists, feedgens, starter_packs, labeler FROM stats WHERE date < ? ORDER BY date DESC LIMIT 1`,
+		date.Format(DATE_FORMAT),
+	)
+	if err := row.Scan(&snapshot.Date, &snapshot.Posts, &snapshot.Follows, &snapshot.Followers, &snapshot.Lists, &snapshot.Feedgens, &snapshot.StarterPacks, &snapshot.Labeler); err != nil {
+		return Snapshot{}, xerrors.Errorf("failed to get stats: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// GetStatsBetween returns the snapshots recorded from one day to another, both inclusive, oldest first.
+func (s *Store) GetStatsBetween(from, to time.Time) ([]Snapshot, error) {
+	rows, err := s.db.Query(
+		`SELECT date, posts, follows, followers, lists, feedgens, starter_packs, labeler FROM stats WHERE date >= ? AND date <= ? ORDER BY date`,
+		from.Format(DATE_FORMAT), to.Format(DATE_FORMAT),
+	)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to query stats: %w", err)
+	}
+
+	defer rows.Close()
+
+	var snapshots []Snapshot
+	for rows.Next() {
+		var snapshot Snapshot
+		if err := rows.Scan(
+			&snapshot.Date, &snapshot.Posts, &snapshot.Follows, &snapshot.Followers,
+			&snapshot.Lists, &snapshot.Feedgens, &snapshot.StarterPacks, &snapshot.Labeler,
+		); err != nil {
+			return nil, xerrors.Errorf("failed to scan stats: %w", err)
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, xerrors.Errorf("failed to iterate stats: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// IsMilestoneAnnounced reports whether the follower milestone has already been announced.
+func (s *Store) IsMilestoneAnnounced(followers int64) (bool, error) {
+	var count int
+
+	row := s.db.QueryRow(`SELECT COUNT(*) FROM milestones WHERE followers = ?`, followers)
+	if err := row.Scan(&count); err != nil {
+		return false, xerrors.Errorf("failed to get milestone: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// SaveMilestone records the follower milestone as announced on the given day.
+func (s *Store) SaveMilestone(date time.Time, followers int64) error {
+	if _, err := s.db.Exec(
+		`INSERT OR IGNORE INTO milestones (followers, date) VALUES (?, ?)`,
+		followers, date.Format(DATE_FORMAT),
+	); err != nil {
+		return xerrors.Errorf("failed to save milestone: %w", err)
+	}
+
+	return nil
+}
+
+// SaveDailyPost records the reference for replying to the daily post of the given day.
+func (s *Store) SaveDailyPost(date time.Time, ref *bsky.FeedPost_ReplyRef) error {
+	if _, err := s.db.Exec(
+		`INSERT INTO daily_posts (date, root_uri, root_cid, parent_uri, parent_cid) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(date) DO UPDATE SET root_uri = excluded.root_uri, root_cid = excluded.root_cid, parent_uri = excluded.parent_uri, parent_cid = excluded.parent_cid`,
+		date.Format(DATE_FORMAT), ref.Root.Uri, ref.Root.Cid, ref.Parent.Uri, ref.Parent.Cid,
+	); err != nil {
+		return xerrors.Errorf("failed to save daily post: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestDailyPostBefore returns the reference for replying to the most recent daily post before the given day.
+// It returns an error wrapping sql.ErrNoRows if no such post exists.
+func (s *Store) GetLatestDailyPostBefore(date time.Time) (*bsky.FeedPost_ReplyRef, error) {
+	ref := &bsky.FeedPost_ReplyRef{
+		Root:   new(atproto.RepoStrongRef),
+		Parent: new(atproto.RepoStrongRef),
+	}
+
+	row := s.db.QueryRow(
+		`SELECT root_uri, root_cid, parent_uri, parent_cid FROM daily_posts WHERE date < ? ORDER BY date DESC LIMIT 1`,
+		date.Format(DATE_FORMAT),
+	)
+	if err := row.Scan(&ref.Root.Uri, &ref.Root.Cid, &ref.Parent.Uri, &ref.Parent.Cid); err != nil {
+		return nil, xerrors.Errorf("failed to get daily post: %w", err)
+	}
+
+	return ref, nil
+}
+
+// SavePostCounts records the breakdown of the posts made on the given day, replacing any existing row.
+func (s *Store) SavePostCounts(date time.Time, counts PostCounts) error {
+	if _, err := s.db.Exec(
+		`INSERT INTO post_counts (date, originals, replies, reposts) VALUES (?, ?, ?, ?)
+		ON CONFLICT(date) DO UPDATE SET originals = excluded.originals, replies = excluded.replies, reposts = excluded.reposts`,
+		date.Format(DATE_FORMAT), counts.Originals, counts.Replies, counts.Reposts,
+	); err != nil {
+		return xerrors.Errorf("failed to save post counts: %w", err)
+	}
+
+	return nil
+}
+
+// GetPostCounts returns the breakdown of the posts made on the given day.
+// It returns zero counts if nothing is recorded for the day.
+func (s *Store) GetPostCounts(date time.Time) (PostCounts, error) {
+	var counts PostCounts
+
+	row := s.db.QueryRow(`SELECT originals, replies, reposts FROM post_counts WHERE date = ?`, date.Format(DATE_FORMAT))
+	if err := row.Scan(&counts.Originals, &counts.Replies, &counts.Reposts); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return PostCounts{}, xerrors.Errorf("failed to get post counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// SaveWatchStats records data as the snapshot of the watched actor on the given day, replacing any existing row.
+func (s *Store) SaveWatchStats(actor string, date time.Time, data Data) error {
+	if _, err := s.db.Exec(
+		`INSERT INTO watch_stats (actor, date, posts, follows, followers) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(actor, date) DO UPDATE SET posts = excluded.posts, follows = excluded.follows, followers = excluded.followers`,
+		actor, date.Format(DATE_FORMAT), data.Posts, data.Follows, data.Followers,
+	); err != nil {
+		return xerrors.Errorf("failed to save watch stats: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestWatchStatsBefore returns the most recent snapshot of the watched actor recorded before the given day.
+// It returns an error wrapping sql.ErrNoRows if no such snapshot exists.
+func (s *Store) GetLatestWatchStatsBefore(actor string, date time.Time) (Data, error) {
+	var data Data
+
+	row := s.db.QueryRow(
+		`SELECT posts, follows, followers FROM watch_stats WHERE actor = ? AND date < ? ORDER BY date DESC LIMIT 1`,
+		actor, date.Format(DATE_FORMAT),
+	)
+	if err := row.Scan(&data.Posts, &data.Follows, &data.Followers); err != nil {
+		return Data{}, xerrors.Errorf("failed to get watch stats: %w", err)
+	}
+
+	return data, nil
+}
+
+// SaveGraph records the actors of the kind, such as followers, as of the given day.
+// Only the snapshot of the day and the one before it are kept.
+func (s *Store) SaveGraph(date time.Time, kind string, actors []Actor) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return xerrors.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM graph WHERE date = ? AND kind = ?`, date.Format(DATE_FORMAT), kind); err != nil {
+		return xerrors.Errorf("failed to delete graph: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		`DELETE FROM graph WHERE kind = ? AND date < (SELECT MAX(date) FROM graph WHERE kind = ? AND date < ?)`,
+		kind, kind, date.Format(DATE_FORMAT),
+	); err != nil {
+		return xerrors.Errorf("failed to prune graph: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO graph (date, kind, did, handle) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return xerrors.Errorf("failed to prepare statement: %w", err)
+	}
+
+	defer stmt.Close()
+
+	for _, actor := range actors {
+		if _, err := stmt.Exec(date.Format(DATE_FORMAT), kind, actor.Did, actor.Handle); err != nil {
+			return xerrors.Errorf("failed to save actor: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return xerrors.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestGraphBefore returns the most recent snapshot of the actors of the kind recorded before the given day.
+// It returns nil if no such snapshot exists.
+func (s *Store) GetLatestGraphBefore(date time.Time, kind string) ([]Actor, error) {
+	rows, err := s.db.Query(
+		`SELECT did, handle FROM graph WHERE kind = ? AND date = (SELECT MAX(date) FROM graph WHERE kind = ? AND date < ?)`,
+		kind, kind, date.Format(DATE_FORMAT),
+	)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to query graph: %w", err)
+	}
+
+	defer rows.Close()
+
+	var actors []Actor
+	for rows.Next() {
+		var actor Actor
+		if err := rows.Scan(&actor.Did, &actor.Handle); err != nil {
+			return nil, xerrors.Errorf("failed to scan actor: %w", err)
+		}
+
+		actors = append(actors, actor)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, xerrors.Errorf("failed to iterate graph: %w", err)
+	}
+
+	return actors, nil
+}
+
+// SaveNotificationCounts records the number of notifications received on the given day by their reasons,
+// replacing any existing counts of the day.
+func (s *Store) SaveNotificationCounts(date time.Time, counts map[string]int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return xerrors.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM notification_counts WHERE date = ?`, date.Format(DATE_FORMAT)); err != nil {
+		return xerrors.Errorf("failed to delete notification counts: %w", err)
+	}
+
+	for reason, count := range counts {
+		if _, err := tx.Exec(
+			`INSERT INTO notification_counts (date, reason, count) VALUES (?, ?, ?)`,
+			date.Format(DATE_FORMAT), reason, count,
+		); err != nil {
+			return xerrors.Errorf("failed to save notification count: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return xerrors.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetNotificationCounts returns the number of notifications received on the given day by their reasons.
+// It returns an empty map if nothing is recorded for the day.
+func (s *Store) GetNotificationCounts(date time.Time) (map[string]int64, error) {
+	rows, err := s.db.Query(`SELECT reason, count FROM notification_counts WHERE date = ?`, date.Format(DATE_FORMAT))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to query notification counts: %w", err)
+	}
+
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var reason string
+		var count int64
+		if err := rows.Scan(&reason, &count); err != nil {
+			return nil, xerrors.Errorf("failed to scan notification count: %w", err)
+		}
+
+		counts[reason] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, xerrors.Errorf("failed to iterate notification counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// SaveDailyReport records the rendered report of the given day, replacing any existing one.
+func (s *Store) SaveDailyReport(date time.Time, text string) error {
+	if _, err := s.db.Exec(
+		`INSERT INTO daily_reports (date, text) VALUES (?, ?) ON CONFLICT(date) DO UPDATE SET text = excluded.text`,
+		date.Format(DATE_FORMAT), text,
+	); err != nil {
+		return xerrors.Errorf("failed to save daily report: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestDailyReports returns up to limit of the most recent reports, newest first.
+func (s *Store) GetLatestDailyReports(limit int) ([]DailyReport, error) {
+	rows, err := s.db.Query(`SELECT date, text FROM daily_reports ORDER BY date DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to query daily reports: %w", err)
+	}
+
+	defer rows.Close()
+
+	var reports []DailyReport
+	for rows.Next() {
+		var report DailyReport
+		if err := rows.Scan(&report.Date, &report.Text); err != nil {
+			return nil, xerrors.Errorf("failed to scan daily report: %w", err)
+		}
+
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, xerrors.Errorf("failed to iterate daily reports: %w", err)
+	}
+
+	return reports, nil
+}
+
+// SaveDailyRun records that the daily job has run on the given day, delivering the report unless it was skipped.
+func (s *Store) SaveDailyRun(date time.Time) error {
+	if _, err := s.db.Exec(`INSERT OR IGNORE INTO daily_runs (date) VALUES (?)`, date.Format(DATE_FORMAT)); err != nil {
+		return xerrors.Errorf("failed to save daily run: %w", err)
+	}
+
+	return nil
+}
+
+// IsDailyRunDone reports whether the daily job has already run on the given day.
+func (s *Store) IsDailyRunDone(date time.Time) (bool, error) {
+	var count int
+
+	row := s.db.QueryRow(`SELECT COUNT(*) FROM daily_runs WHERE date = ?`, date.Format(DATE_FORMAT))
+	if err := row.Scan(&count); err != nil {
+		return false, xerrors.Errorf("failed to get daily run: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// SaveDailyDelivery records that the daily report of the given day was delivered to the sink.
+func (s *Store) SaveDailyDelivery(date time.Time, sink string) error {
+	if _, err := s.db.Exec(`INSERT OR IGNORE INTO daily_deliveries (date, sink) VALUES (?, ?)`, date.Format(DATE_FORMAT), sink); err != nil {
+		return xerrors.Errorf("failed to save daily delivery: %w", err)
+	}
+
+	return nil
+}
+
+// IsDailyDelivered reports whether the daily report of the given day has already been delivered to the sink.
+func (s *Store) IsDailyDelivered(date time.Time, sink string) (bool, error) {
+	var count int
+
+	row := s.db.QueryRow(`SELECT COUNT(*) FROM daily_deliveries WHERE date = ? AND sink = ?`, date.Format(DATE_FORMAT), sink)
+	if err := row.Scan(&count); err != nil {
+		return false, xerrors.Errorf("failed to get daily delivery: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// SavePeriodicPost records that the periodic post of the kind, such as the weekly summary, has been made for the period.
+func (s *Store) SavePeriodicPost(kind, period string) error {
+	if _, err := s.db.Exec(`INSERT OR IGNORE INTO periodic_posts (kind, period) VALUES (?, ?)`, kind, period); err != nil {
+		return xerrors.Errorf("failed to save periodic post: %w", err)
+	}
+
+	return nil
+}
+
+// IsPeriodicPosted reports whether the periodic post of the kind has already been made for the period.
+func (s *Store) IsPeriodicPosted(kind, period string) (bool, error) {
+	var count int
+
+	row := s.db.QueryRow(`SELECT COUNT(*) FROM periodic_posts WHERE kind = ? AND period = ?`, kind, period)
+	if err := row.Scan(&count); err != nil {
+		return false, xerrors.Errorf("failed to get periodic post: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// GetLatestDailyRun returns the most recent day the daily job has run on, in DATE_FORMAT.
+// It returns an error wrapping sql.ErrNoRows if the job has never run.
+func (s *Store) GetLatestDailyRun() (string, error) {
+	var date string
+
+	row := s.db.QueryRow(`SELECT date FROM daily_runs ORDER BY date DESC LIMIT 1`)
+	if err := row.Scan(&date); err != nil {
+		return "", xerrors.Errorf("failed to get daily run: %w", err)
+	}
+
+	return date, nil
+}
+
+// SaveIntervalStats records data as the snapshot taken at the given time by the interval job.
+// Only the snapshots of the last day are kept.
+func (s *Store) SaveIntervalStats(t time.Time, data Data) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return xerrors.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`INSERT INTO interval_stats (time, posts, follows, followers, lists, feedgens, starter_packs, labeler) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(time) DO UPDATE SET posts = excluded.posts, follows = excluded.follows, followers = excluded.followers,
+		lists = excluded.lists, feedgens = excluded.feedgens, starter_packs = excluded.starter_packs, labeler = excluded.labeler`,
+		t.UTC().Format(TIME_FORMAT), data.Posts, data.Follows, data.Followers, data.Lists, data.Feedgens, data.StarterPacks, data.Labeler,
+	); err != nil {
+		return xerrors.Errorf("failed to save interval stats: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM interval_stats WHERE time < ?`, t.UTC().AddDate(0, 0, -1).Format(TIME_FORMAT)); err != nil {
+		return xerrors.Errorf("failed to delete old interval stats: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return xerrors.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestIntervalStats returns the most recent snapshot taken by the interval job and the time it was taken at.
+// It returns an error wrapping sql.ErrNoRows if no such snapshot exists.
+func (s *Store) GetLatestIntervalStats() (time.Time, Data, error) {
+	var (
+		value string
+		data  Data
+	)
+
+	row := s.db.QueryRow(
+		`SELECT time, posts, follows, followers, lists, feedgens, starter_packs, labeler FROM interval_stats ORDER BY time DESC LIMIT 1`,
+	)
+	if err := row.Scan(&value, &data.Posts, &data.Follows, &data.Followers, &data.Lists, &data.Feedgens, &data.StarterPacks, &data.Labeler); err != nil {
+		return time.Time{}, Data{}, xerrors.Errorf("failed to get interval stats: %w", err)
+	}
+
+	t, err := time.Parse(TIME_FORMAT, value)
+	if err != nil {
+		return time.Time{}, Data{}, xerrors.Errorf("failed to parse time: %w", err)
+	}
+
+	return t, data, nil
+}
@@ -0,0 +1,420 @@
+package bskystats
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	store, err := OpenStore(filepath.Join(t.TempDir(), STATS_DB))
+	if err != nil {
+		t.Fatalf("OpenStore() error = %v", err)
+	}
+
+	t.Cleanup(func() { store.Close() })
+
+	return store
+}
+
+func day(s string) time.Time {
+	t, err := time.Parse(DATE_FORMAT, s)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+func TestStoreStats(t *testing.T) {
+	store := openTestStore(t)
+
+	if _, err := store.GetLatestStatsBefore(day("2026-01-10")); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetLatestStatsBefore() on an empty store error = %v, want sql.ErrNoRows", err)
+	}
+
+	first := Data{Posts: 1, Follows: 2, Followers: 3, Lists: 4, Feedgens: 5, StarterPacks: 6, Labeler: true}
+	second := Data{Posts: 10, Follows: 20, Followers: 30}
+
+	if err := store.SaveStats(day("2026-01-08"), first); err != nil {
+		t.Fatalf("SaveStats() error = %v", err)
+	}
+
+	if err := store.SaveStats(day("2026-01-09"), Data{Posts: 99}); err != nil {
+		t.Fatalf("SaveStats() error = %v", err)
+	}
+
+	// A second save of the same day replaces the row.
+	if err := store.SaveStats(day("2026-01-09"), second); err != nil {
+		t.Fatalf("SaveStats() error = %v", err)
+	}
+
+	// InitStats leaves an existing row alone.
+	if err := store.InitStats(day("2026-01-09"), Data{Posts: 42}); err != nil {
+		t.Fatalf("InitStats() error = %v", err)
+	}
+
+	tests := []struct {
+		date string
+		want Snapshot
+	}{
+		{"2026-01-10", Snapshot{Date: "2026-01-09", Data: second}},
+		{"2026-01-09", Snapshot{Date: "2026-01-08", Data: first}},
+	}
+
+	for _, tt := range tests {
+		got, err := store.GetLatestStatsBefore(day(tt.date))
+		if err != nil {
+			t.Fatalf("GetLatestStatsBefore(%s) error = %v", tt.date, err)
+		}
+
+		if got != tt.want {
+			t.Errorf("GetLatestStatsBefore(%s) = %+v, want %+v", tt.date, got, tt.want)
+		}
+	}
+
+	snapshots, err := store.GetStatsBetween(day("2026-01-01"), day("2026-01-09"))
+	if err != nil {
+		t.Fatalf("GetStatsBetween() error = %v", err)
+	}
+
+	want := []Snapshot{{Date: "2026-01-08", Data: first}, {Date: "2026-01-09", Data: second}}
+	if !slices.Equal(snapshots, want) {
+		t.Errorf("GetStatsBetween() = %+v, want %+v", snapshots, want)
+	}
+}
+
+func TestStoreMilestones(t *testing.T) {
+	store := openTestStore(t)
+
+	if err := store.SaveMilestone(day("2026-01-09"), 1000); err != nil {
+		t.Fatalf("SaveMilestone() error = %v", err)
+	}
+
+	for followers, want := range map[int64]bool{1000: true, 2000: false} {
+		got, err := store.IsMilestoneAnnounced(followers)
+		if err != nil {
+			t.Fatalf("IsMilestoneAnnounced(%d) error = %v", followers, err)
+		}
+
+		if got != want {
+			t.Errorf("IsMilestoneAnnounced(%d) = %v, want %v", followers, got, want)
+		}
+	}
+}
+
+func TestStoreDailyPosts(t *testing.T) {
+	store := openTestStore(t)
+
+	if _, err := store.GetLatestDailyPostBefore(day("2026-01-10")); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetLatestDailyPostBefore() on an empty store error = %v, want sql.ErrNoRows", err)
+	}
+
+	ref := &bsky.FeedPost_ReplyRef{
+		Root:   &atproto.RepoStrongRef{Uri: "at://did:plc:a/app.bsky.feed.post/1", Cid: "root"},
+		Parent: &atproto.RepoStrongRef{Uri: "at://did:plc:a/app.bsky.feed.post/2", Cid: "parent"},
+	}
+
+	if err := store.SaveDailyPost(day("2026-01-09"), ref); err != nil {
+		t.Fatalf("SaveDailyPost() error = %v", err)
+	}
+
+	got, err := store.GetLatestDailyPostBefore(day("2026-01-10"))
+	if err != nil {
+		t.Fatalf("GetLatestDailyPostBefore() error = %v", err)
+	}
+
+	if *got.Root != *ref.Root || *got.Parent != *ref.Parent {
+		t.Errorf("GetLatestDailyPostBefore() = %+v %+v, want %+v %+v", got.Root, got.Parent, ref.Root, ref.Parent)
+	}
+}
+
+func TestStorePostCounts(t *testing.T) {
+	store := openTestStore(t)
+
+	counts := PostCounts{Originals: 3, Replies: 2, Reposts: 1}
+	if err := store.SavePostCounts(day("2026-01-09"), counts); err != nil {
+		t.Fatalf("SavePostCounts() error = %v", err)
+	}
+
+	tests := []struct {
+		date string
+		want PostCounts
+	}{
+		{"2026-01-09", counts},
+		{"2026-01-10", PostCounts{}},
+	}
+
+	for _, tt := range tests {
+		got, err := store.GetPostCounts(day(tt.date))
+		if err != nil {
+			t.Fatalf("GetPostCounts(%s) error = %v", tt.date, err)
+		}
+
+		if got != tt.want {
+			t.Errorf("GetPostCounts(%s) = %+v, want %+v", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestStoreWatchStats(t *testing.T) {
+	store := openTestStore(t)
+
+	alice := Data{Posts: 1, Follows: 2, Followers: 3}
+	if err := store.SaveWatchStats("alice.example", day("2026-01-09"), alice); err != nil {
+		t.Fatalf("SaveWatchStats() error = %v", err)
+	}
+
+	if err := store.SaveWatchStats("bob.example", day("2026-01-09"), Data{Posts: 7}); err != nil {
+		t.Fatalf("SaveWatchStats() error = %v", err)
+	}
+
+	got, err := store.GetLatestWatchStatsBefore("alice.example", day("2026-01-10"))
+	if err != nil {
+		t.Fatalf("GetLatestWatchStatsBefore() error = %v", err)
+	}
+
+	if got != alice {
+		t.Errorf("GetLatestWatchStatsBefore() = %+v, want %+v", got, alice)
+	}
+
+	if _, err := store.GetLatestWatchStatsBefore("carol.example", day("2026-01-10")); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetLatestWatchStatsBefore() of an unknown actor error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestStoreGraph(t *testing.T) {
+	store := openTestStore(t)
+
+	snapshots := []struct {
+		date   string
+		actors []Actor
+	}{
+		{"2026-01-07", []Actor{{Did: "did:plc:a", Handle: "a.example"}}},
+		{"2026-01-08", []Actor{{Did: "did:plc:b", Handle: "b.example"}}},
+		{"2026-01-09", []Actor{{Did: "did:plc:c", Handle: "c.example"}}},
+	}
+
+	for _, snapshot := range snapshots {
+		if err := store.SaveGraph(day(snapshot.date), "followers", snapshot.actors); err != nil {
+			t.Fatalf("SaveGraph(%s) error = %v", snapshot.date, err)
+		}
+	}
+
+	// The graph of another kind is kept apart.
+	if err := store.SaveGraph(day("2026-01-09"), "follows", []Actor{{Did: "did:plc:z", Handle: "z.example"}}); err != nil {
+		t.Fatalf("SaveGraph() error = %v", err)
+	}
+
+	tests := []struct {
+		date string
+		want []Actor
+	}{
+		{"2026-01-10", snapshots[2].actors},
+		{"2026-01-09", snapshots[1].actors},
+		// Only the last two snapshots are kept.
+		{"2026-01-08", nil},
+	}
+
+	for _, tt := range tests {
+		got, err := store.GetLatestGraphBefore(day(tt.date), "followers")
+		if err != nil {
+			t.Fatalf("GetLatestGraphBefore(%s) error = %v", tt.date, err)
+		}
+
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("GetLatestGraphBefore(%s) = %+v, want %+v", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestStoreNotificationCounts(t *testing.T) {
+	store := openTestStore(t)
+
+	if err := store.SaveNotificationCounts(day("2026-01-09"), map[string]int64{"like": 5, "repost": 1}); err != nil {
+		t.Fatalf("SaveNotificationCounts() error = %v", err)
+	}
+
+	// A second save replaces the counts of the day.
+	want := map[string]int64{"like": 7, "reply": 2}
+	if err := store.SaveNotificationCounts(day("2026-01-09"), want); err != nil {
+		t.Fatalf("SaveNotificationCounts() error = %v", err)
+	}
+
+	got, err := store.GetNotificationCounts(day("2026-01-09"))
+	if err != nil {
+		t.Fatalf("GetNotificationCounts() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetNotificationCounts() = %v, want %v", got, want)
+	}
+
+	empty, err := store.GetNotificationCounts(day("2026-01-10"))
+	if err != nil {
+		t.Fatalf("GetNotificationCounts() error = %v", err)
+	}
+
+	if len(empty) != 0 {
+		t.Errorf("GetNotificationCounts() of a day without counts = %v, want empty", empty)
+	}
+}
+
+func TestStoreDailyReports(t *testing.T) {
+	store := openTestStore(t)
+
+	for _, report := range []DailyReport{
+		{Date: "2026-01-07", Text: "a"},
+		{Date: "2026-01-08", Text: "b"},
+		{Date: "2026-01-09", Text: "old"},
+		{Date: "2026-01-09", Text: "c"},
+	} {
+		if err := store.SaveDailyReport(day(report.Date), report.Text); err != nil {
+			t.Fatalf("SaveDailyReport(%s) error = %v", report.Date, err)
+		}
+	}
+
+	got, err := store.GetLatestDailyReports(2)
+	if err != nil {
+		t.Fatalf("GetLatestDailyReports() error = %v", err)
+	}
+
+	want := []DailyReport{{Date: "2026-01-09", Text: "c"}, {Date: "2026-01-08", Text: "b"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("GetLatestDailyReports() = %+v, want %+v", got, want)
+	}
+}
+
+func TestStoreDailyRuns(t *testing.T) {
+	store := openTestStore(t)
+
+	if _, err := store.GetLatestDailyRun(); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetLatestDailyRun() on an empty store error = %v, want sql.ErrNoRows", err)
+	}
+
+	for _, date := range []string{"2026-01-09", "2026-01-08", "2026-01-09"} {
+		if err := store.SaveDailyRun(day(date)); err != nil {
+			t.Fatalf("SaveDailyRun(%s) error = %v", date, err)
+		}
+	}
+
+	latest, err := store.GetLatestDailyRun()
+	if err != nil {
+		t.Fatalf("GetLatestDailyRun() error = %v", err)
+	}
+
+	if latest != "2026-01-09" {
+		t.Errorf("GetLatestDailyRun() = %s, want 2026-01-09", latest)
+	}
+
+	for date, want := range map[string]bool{"2026-01-09": true, "2026-01-10": false} {
+		done, err := store.IsDailyRunDone(day(date))
+		if err != nil {
+			t.Fatalf("IsDailyRunDone(%s) error = %v", date, err)
+		}
+
+		if done != want {
+			t.Errorf("IsDailyRunDone(%s) = %v, want %v", date, done, want)
+		}
+	}
+}
+
+func TestStoreDailyDeliveries(t *testing.T) {
+	store := openTestStore(t)
+
+	if err := store.SaveDailyDelivery(day("2026-01-09"), "post"); err != nil {
+		t.Fatalf("SaveDailyDelivery() error = %v", err)
+	}
+
+	tests := []struct {
+		date string
+		sink string
+		want bool
+	}{
+		{"2026-01-09", "post", true},
+		{"2026-01-09", "discord", false},
+		{"2026-01-10", "post", false},
+	}
+
+	for _, tt := range tests {
+		got, err := store.IsDailyDelivered(day(tt.date), tt.sink)
+		if err != nil {
+			t.Fatalf("IsDailyDelivered(%s, %s) error = %v", tt.date, tt.sink, err)
+		}
+
+		if got != tt.want {
+			t.Errorf("IsDailyDelivered(%s, %s) = %v, want %v", tt.date, tt.sink, got, tt.want)
+		}
+	}
+}
+
+func TestStorePeriodicPosts(t *testing.T) {
+	store := openTestStore(t)
+
+	if err := store.SavePeriodicPost("weekly", "2026-01-12"); err != nil {
+		t.Fatalf("SavePeriodicPost() error = %v", err)
+	}
+
+	// Saving it again is not an error.
+	if err := store.SavePeriodicPost("weekly", "2026-01-12"); err != nil {
+		t.Fatalf("SavePeriodicPost() again error = %v", err)
+	}
+
+	tests := []struct {
+		kind   string
+		period string
+		want   bool
+	}{
+		{"weekly", "2026-01-12", true},
+		{"weekly", "2026-01-19", false},
+		{"monthly", "2026-01-12", false},
+	}
+
+	for _, tt := range tests {
+		got, err := store.IsPeriodicPosted(tt.kind, tt.period)
+		if err != nil {
+			t.Fatalf("IsPeriodicPosted(%s, %s) error = %v", tt.kind, tt.period, err)
+		}
+
+		if got != tt.want {
+			t.Errorf("IsPeriodicPosted(%s, %s) = %v, want %v", tt.kind, tt.period, got, tt.want)
+		}
+	}
+}
+
+func TestStoreIntervalStats(t *testing.T) {
+	store := openTestStore(t)
+
+	if _, _, err := store.GetLatestIntervalStats(); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetLatestIntervalStats() on an empty store error = %v, want sql.ErrNoRows", err)
+	}
+
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	data := Data{Posts: 1, Follows: 2, Followers: 3}
+
+	if err := store.SaveIntervalStats(now.Add(-6*time.Hour), Data{Posts: 9}); err != nil {
+		t.Fatalf("SaveIntervalStats() error = %v", err)
+	}
+
+	if err := store.SaveIntervalStats(now, data); err != nil {
+		t.Fatalf("SaveIntervalStats() error = %v", err)
+	}
+
+	at, got, err := store.GetLatestIntervalStats()
+	if err != nil {
+		t.Fatalf("GetLatestIntervalStats() error = %v", err)
+	}
+
+	if !at.Equal(now) || got != data {
+		t.Errorf("GetLatestIntervalStats() = %v %+v, want %v %+v", at, got, now, data)
+	}
+}